@@ -4,8 +4,12 @@ import (
 	"context"
 	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"log"
 	"net/http"
+	"os"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -13,37 +17,246 @@ import (
 	"github.com/jthughes/chirpynetwork/internal/database"
 )
 
+// jwksCacheControl lets verifiers cache the published keys briefly. A new
+// key must be published for longer than this before tokens are signed with
+// it.
+const jwksCacheControl = "public, max-age=300"
+
+// jwtKeysFromEnv loads the keys access tokens are signed and verified with
+// from the PEM files listed, comma separated, in JWT_KEYS. The first must be
+// a private key and signs new tokens; the others only verify them. Outside of
+// dev, where a key made at startup will do, JWT_KEYS must be set.
+func jwtKeysFromEnv(platform string) (*auth.KeySet, error) {
+	value := os.Getenv("JWT_KEYS")
+	if value == "" {
+		if platform != "dev" {
+			return nil, fmt.Errorf("JWT_KEYS must be set")
+		}
+		key, err := auth.GenerateKey()
+		if err != nil {
+			return nil, err
+		}
+		log.Printf("JWT_KEYS not set, signing access tokens with temporary key %s", key.ID)
+		return auth.NewKeySet(key)
+	}
+
+	keys := []auth.Key{}
+	for _, path := range strings.Split(value, ",") {
+		data, err := os.ReadFile(strings.TrimSpace(path))
+		if err != nil {
+			return nil, err
+		}
+		key, err := auth.ParseKeyPEM(data)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		keys = append(keys, key)
+	}
+	return auth.NewKeySet(keys...)
+}
+
+// handlerJWKS publishes the public keys access tokens can be verified with.
+func (cfg *apiConfig) handlerJWKS(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Cache-Control", jwksCacheControl)
+	w.Header().Set("Content-Type", "application/json")
+	data, err := json.Marshal(cfg.jwtKeys.JWKS())
+	SetJSONResponse(w, http.StatusOK, data, err)
+}
+
+// principalKey is the request context key the caller's access token claims
+// are stored under.
+type principalKey struct{}
+
+// middlewareAuthenticate identifies the caller of every request from the
+// access token in its Authorization header, either a JWT or a personal access
+// token, and attaches the token's claims to the request context. Requests
+// without a valid access token carry on anonymously; routes that need one are
+// wrapped in requireScopes.
+func (cfg *apiConfig) middlewareAuthenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			token, err := auth.GetBearerToken(r.Header)
+			if err == nil {
+				var claims auth.Claims
+				if auth.IsPersonalAccessToken(token) {
+					claims, err = cfg.validatePersonalAccessToken(r.Context(), token)
+				} else {
+					claims, err = cfg.validateAccessToken(r.Context(), token)
+				}
+				if err == nil {
+					r = r.WithContext(context.WithValue(r.Context(), principalKey{}, claims))
+				}
+			}
+			next.ServeHTTP(w, r)
+		})
+}
+
+// validateAccessToken checks a JWT access token, and that the session it was
+// issued from hasn't been revoked since, so logging a device out takes
+// effect straight away rather than when its access token expires.
+func (cfg *apiConfig) validateAccessToken(ctx context.Context, token string) (auth.Claims, error) {
+	claims, err := auth.ValidateJWT(token, cfg.jwtKeys)
+	if err != nil || !claims.SessionID.Valid {
+		return claims, err
+	}
+	revoked, err := cfg.db.IsSessionRevoked(ctx, claims.SessionID.UUID)
+	if err != nil {
+		log.Printf("Error checking session %s: %s", claims.SessionID.UUID, err)
+		return auth.Claims{}, err
+	}
+	if revoked {
+		return auth.Claims{}, fmt.Errorf("session revoked")
+	}
+	return claims, nil
+}
+
+// principal returns the claims middlewareAuthenticate attached to r.
+func principal(r *http.Request) (auth.Claims, bool) {
+	claims, ok := r.Context().Value(principalKey{}).(auth.Claims)
+	return claims, ok
+}
+
+// requireScopes only lets requests through to next when their access token
+// grants every one of scopes. Without scopes, any valid access token will do.
+func (cfg *apiConfig) requireScopes(next http.HandlerFunc, scopes ...string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		claims, ok := principal(r)
+		if !ok {
+			ResponseError(w, nil, "User not authenticated", http.StatusUnauthorized)
+			return
+		}
+		for _, scope := range scopes {
+			if !claims.HasScope(scope) {
+				ResponseErrorDetail(w, nil, "Access token is missing a required scope", http.StatusForbidden, map[string]string{"scope": scope})
+				return
+			}
+		}
+		next(w, r)
+	}
+}
+
 func (cfg *apiConfig) authenticateRequest(r *http.Request) (uuid.UUID, error) {
-	token, err := auth.GetBearerToken(r.Header)
+	userId, _, err := cfg.authenticateSession(r)
+	return userId, err
+}
+
+// authenticateSession is authenticateRequest that also returns the session
+// the access token was issued from. Tokens issued before sessions were
+// recorded in them have none.
+func (cfg *apiConfig) authenticateSession(r *http.Request) (uuid.UUID, uuid.NullUUID, error) {
+	claims, ok := principal(r)
+	if !ok {
+		return uuid.UUID{}, uuid.NullUUID{}, fmt.Errorf("missing or invalid access token")
+	}
+	return claims.UserID, claims.SessionID, nil
+}
+
+// authenticateAdmin is authenticateRequest for endpoints restricted to
+// administrators. It reports whether the caller is authenticated but not an
+// administrator separately, so handlers can answer with 403 rather than 401.
+func (cfg *apiConfig) authenticateAdmin(r *http.Request) (userID uuid.UUID, isAdmin bool, err error) {
+	userID, err = cfg.authenticateRequest(r)
+	if err != nil {
+		return uuid.UUID{}, false, err
+	}
+	user, err := cfg.db.GetUserById(r.Context(), userID)
 	if err != nil {
-		return uuid.UUID{}, fmt.Errorf("access token not found")
+		return uuid.UUID{}, false, fmt.Errorf("user not found")
 	}
-	userId, err := auth.ValidateJWT(token, cfg.secretKey)
+	return userID, user.IsAdmin, nil
+}
+
+// optionalViewer identifies the caller of a public endpoint. Requests without
+// a valid access token are treated as anonymous.
+func (cfg *apiConfig) optionalViewer(r *http.Request) uuid.NullUUID {
+	userID, err := cfg.authenticateRequest(r)
 	if err != nil {
-		return uuid.UUID{}, fmt.Errorf("invalid access token")
+		return uuid.NullUUID{}
 	}
-	return userId, nil
+	return uuid.NullUUID{UUID: userID, Valid: true}
 }
 
-func (cfg *apiConfig) authenticateRefresh(r *http.Request) (string, uuid.UUID, error) {
+// refreshTokenExpiry is how long a refresh token can be used. Every refresh
+// replaces the token, so a session lasts as long as it is used at least this
+// often.
+const refreshTokenExpiry = 60 * 24 * time.Hour
+
+// errRefreshTokenReused is returned for a token that was already rotated.
+var errRefreshTokenReused = errors.New("refresh token already used")
+
+func (cfg *apiConfig) authenticateRefresh(r *http.Request) (database.RefreshToken, error) {
 	token, err := auth.GetBearerToken(r.Header)
 	if err != nil {
-		return "", uuid.UUID{}, fmt.Errorf("invalid authorization header")
+		return database.RefreshToken{}, fmt.Errorf("invalid authorization header")
+	}
+	dbToken, err := cfg.db.GetRefreshToken(r.Context(), auth.HashToken(token))
+	if err != nil {
+		return database.RefreshToken{}, fmt.Errorf("refresh token not found")
+	}
+	if dbToken.RevokedAt.Valid {
+		return database.RefreshToken{}, fmt.Errorf("refresh token revoked")
+	}
+	if dbToken.RotatedAt.Valid {
+		cfg.revokeReusedRefreshToken(r.Context(), dbToken)
+		return database.RefreshToken{}, errRefreshTokenReused
+	}
+	if dbToken.ExpiresAt.Before(time.Now()) {
+		return database.RefreshToken{}, fmt.Errorf("refresh token expired")
+	}
+	return dbToken, nil
+}
+
+// revokeReusedRefreshToken handles a rotated refresh token being presented
+// again. Only one of the legitimate client and whoever copied the token
+// should have it, and there is no telling which one this is, so the whole
+// family is revoked and both have to log in again.
+func (cfg *apiConfig) revokeReusedRefreshToken(ctx context.Context, dbToken database.RefreshToken) {
+	log.Printf("Security event: reuse of rotated refresh token for user %s, revoking token family %s", dbToken.UserID, dbToken.FamilyID)
+	err := cfg.db.RevokeRefreshTokenFamily(ctx, database.RevokeRefreshTokenFamilyParams{
+		FamilyID: dbToken.FamilyID,
+		RevokedAt: sql.NullTime{
+			Time:  time.Now(),
+			Valid: true,
+		},
+	})
+	if err != nil {
+		log.Printf("Error revoking refresh token family %s: %s", dbToken.FamilyID, err)
 	}
-	dbToken, err := cfg.db.GetRefreshToken(r.Context(), token)
+}
+
+// issueRefreshToken stores a new refresh token for userID in the token family
+// familyID, used from device. Logging in starts a new family; refreshing
+// continues one. Only the token's digest is stored, so the returned token is
+// the one chance to hand it to the client.
+func issueRefreshToken(ctx context.Context, q *database.Queries, userID, familyID uuid.UUID, device sessionDevice) (string, error) {
+	token, err := auth.MakeRefreshToken()
 	if err != nil {
-		return "", uuid.UUID{}, fmt.Errorf("refresh token not found")
+		return "", err
 	}
-	if dbToken.ExpiresAt.Before(time.Now()) || dbToken.RevokedAt.Valid {
-		return "", uuid.UUID{}, fmt.Errorf("refresh token expired")
+	now := time.Now()
+	_, err = q.StoreRefreshToken(ctx, database.StoreRefreshTokenParams{
+		TokenHash:   auth.HashToken(token),
+		CreatedAt:   now,
+		UpdatedAt:   now,
+		UserID:      userID,
+		ExpiresAt:   now.Add(refreshTokenExpiry),
+		FamilyID:    familyID,
+		DeviceLabel: device.Label,
+		UserAgent:   device.UserAgent,
+		IpAddress:   device.IPAddress,
+		LastUsedAt:  now,
+	})
+	if err != nil {
+		return "", err
 	}
-	return dbToken.Token, dbToken.UserID, nil
+	return token, nil
 }
 
 func (cfg *apiConfig) handlerLogin(w http.ResponseWriter, r *http.Request) {
 	type request struct {
-		Password string `json:"password"`
-		Email    string `json:"email"`
+		Password    string `json:"password"`
+		Email       string `json:"email"`
+		DeviceLabel string `json:"device_label"`
 	}
 
 	decoder := json.NewDecoder(r.Body)
@@ -54,40 +267,63 @@ func (cfg *apiConfig) handlerLogin(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	device, err := cfg.requestDevice(r, test.DeviceLabel)
+	if err != nil {
+		ResponseError(w, err, fmt.Sprintf("Invalid device label: %s", err), http.StatusBadRequest)
+		return
+	}
+
+	// Count the attempt as a failure before the password is checked, so
+	// attempts made at once can't get around the hold-off
+	throttles := cfg.loginThrottles(r, test.Email)
+	attempt, err := cfg.reserveLoginAttempt(context.Background(), throttles)
+	if err != nil {
+		ResponseError(w, err, "Error recording login attempt", http.StatusInternalServerError)
+		return
+	}
+	if attempt.Wait > 0 {
+		seconds := setRetryAfter(w, attempt.Wait)
+		ResponseErrorDetail(w, nil, "Too many failed login attempts", http.StatusTooManyRequests, map[string]int{"retry_after": seconds})
+		return
+	}
+
 	// Validate login
 	dbUser, err := cfg.db.GetUserByEmail(context.Background(), test.Email)
+	userID := uuid.NullUUID{UUID: dbUser.ID, Valid: err == nil}
 	if err == nil {
 		err = auth.CheckPasswordHash(test.Password, dbUser.HashedPassword)
 	}
 	if err != nil {
+		err = cfg.failLoginAttempt(context.Background(), attempt, userID, device.IPAddress)
+		if err != nil {
+			ResponseError(w, err, "Error recording login attempt", http.StatusInternalServerError)
+			return
+		}
+		if attempt.Next > 0 {
+			setRetryAfter(w, attempt.Next)
+		}
 		ResponseError(w, nil, "Incorrect email or password", http.StatusUnauthorized)
 		return
 	}
 
-	// Create access token
-	accessToken, err := auth.MakeAccessToken(dbUser.ID, cfg.secretKey)
+	err = cfg.succeedLoginAttempt(context.Background(), throttles)
 	if err != nil {
-		ResponseError(w, err, "Error creating authentication token", http.StatusInternalServerError)
+		ResponseError(w, err, "Error recording login attempt", http.StatusInternalServerError)
 		return
 	}
 
-	// Create refresh token
-	refreshExpiry := 60 * 24 * time.Hour
-	refreshTokenString, err := auth.MakeRefreshToken()
+	// Create refresh token, starting a new session
+	sessionID := uuid.New()
+	refreshToken, err := issueRefreshToken(context.Background(), cfg.db, dbUser.ID, sessionID, device)
 	if err != nil {
-		ResponseError(w, err, "Error creating refresh token", http.StatusInternalServerError)
+		ResponseError(w, err, "Error storing refresh token", http.StatusInternalServerError)
 		return
 	}
 
-	refreshToken, err := cfg.db.StoreRefreshToken(context.Background(), database.StoreRefreshTokenParams{
-		Token:     refreshTokenString,
-		CreatedAt: time.Now(),
-		UpdatedAt: time.Now(),
-		UserID:    dbUser.ID,
-		ExpiresAt: time.Now().Add(refreshExpiry),
-	})
+	// Create access token
+	accessToken, err := auth.MakeAccessToken(dbUser.ID, sessionID, auth.UserScopes(dbUser.IsAdmin), cfg.jwtKeys)
 	if err != nil {
-		ResponseError(w, err, "Error storing refresh token", http.StatusInternalServerError)
+		ResponseError(w, err, "Error creating authentication token", http.StatusInternalServerError)
 		return
 	}
 
@@ -101,43 +337,98 @@ func (cfg *apiConfig) handlerLogin(w http.ResponseWriter, r *http.Request) {
 	data, err := json.Marshal(response{
 		User:         dbUserToUser(dbUser),
 		AccessToken:  accessToken,
-		RefreshToken: refreshToken.Token,
+		RefreshToken: refreshToken,
 	})
 	SetJSONResponse(w, http.StatusOK, data, err)
 }
 
+// handlerRefresh swaps a refresh token for a new access token and a new
+// refresh token. The presented token is retired, and presenting it again
+// revokes every token descended from the same login.
 func (cfg *apiConfig) handlerRefresh(w http.ResponseWriter, r *http.Request) {
 
-	_, userID, err := cfg.authenticateRefresh(r)
+	dbToken, err := cfg.authenticateRefresh(r)
 	if err != nil {
 		ResponseError(w, err, "Invalid refresh token", http.StatusUnauthorized)
 		return
 	}
 
-	accessToken, err := auth.MakeAccessToken(userID, cfg.secretKey)
+	tx, err := cfg.dbConn.BeginTx(context.Background(), nil)
+	if err != nil {
+		ResponseError(w, err, "Error rotating refresh token", http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.db.WithTx(tx)
+
+	_, err = qtx.RotateRefreshToken(context.Background(), database.RotateRefreshTokenParams{
+		TokenHash: dbToken.TokenHash,
+		Now:       time.Now(),
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		// Another request rotated or revoked the token since it was checked
+		tx.Rollback()
+		_, err = cfg.authenticateRefresh(r)
+		ResponseError(w, err, "Invalid refresh token", http.StatusUnauthorized)
+		return
+	}
+	if err != nil {
+		ResponseError(w, err, "Error rotating refresh token", http.StatusInternalServerError)
+		return
+	}
+
+	// The device label stays with the session; the rest is where it was
+	// last used from
+	device, _ := cfg.requestDevice(r, "")
+	device.Label = dbToken.DeviceLabel
+	refreshToken, err := issueRefreshToken(context.Background(), qtx, dbToken.UserID, dbToken.FamilyID, device)
+	if err != nil {
+		ResponseError(w, err, "Error storing refresh token", http.StatusInternalServerError)
+		return
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		ResponseError(w, err, "Error rotating refresh token", http.StatusInternalServerError)
+		return
+	}
+
+	// Scopes follow the user's current role, so a demoted admin loses the
+	// admin scope at their next refresh
+	dbUser, err := cfg.db.GetUserById(context.Background(), dbToken.UserID)
+	if err != nil {
+		ResponseError(w, err, "Error retrieving user", http.StatusInternalServerError)
+		return
+	}
+
+	accessToken, err := auth.MakeAccessToken(dbUser.ID, dbToken.FamilyID, auth.UserScopes(dbUser.IsAdmin), cfg.jwtKeys)
 	if err != nil {
 		ResponseError(w, err, "Error creating authentication token", http.StatusInternalServerError)
 		return
 	}
 
 	type response struct {
-		AccessToken string `json:"token"`
+		AccessToken  string `json:"token"`
+		RefreshToken string `json:"refresh_token"`
 	}
 	data, err := json.Marshal(response{
-		AccessToken: accessToken,
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
 	})
 	SetJSONResponse(w, http.StatusOK, data, err)
 }
 
+// handlerRevoke logs out the session a refresh token belongs to by revoking
+// its whole family.
 func (cfg *apiConfig) handlerRevoke(w http.ResponseWriter, r *http.Request) {
-	token, _, err := cfg.authenticateRefresh(r)
+	dbToken, err := cfg.authenticateRefresh(r)
 	if err != nil {
 		ResponseError(w, err, "Invalid refresh token", http.StatusNotFound)
 		return
 	}
 
-	_, err = cfg.db.RevokeRefreshToken(context.Background(), database.RevokeRefreshTokenParams{
-		Token: token,
+	err = cfg.db.RevokeRefreshTokenFamily(context.Background(), database.RevokeRefreshTokenFamilyParams{
+		FamilyID: dbToken.FamilyID,
 		RevokedAt: sql.NullTime{
 			Time:  time.Now(),
 			Valid: true,
@@ -0,0 +1,167 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jthughes/chirpynetwork/internal/database"
+)
+
+// parseTargetUser reads the user in the path of a block or mute request and
+// checks that it is someone other than the caller. It writes the error
+// response itself and reports whether the request can go ahead.
+func (cfg *apiConfig) parseTargetUser(w http.ResponseWriter, r *http.Request, userID uuid.UUID) (uuid.UUID, bool) {
+	targetID, err := uuid.Parse(r.PathValue("userID"))
+	if err != nil {
+		ResponseError(w, err, "Error parsing user id", http.StatusBadRequest)
+		return uuid.Nil, false
+	}
+	if targetID == userID {
+		ResponseError(w, nil, "Users cannot block or mute themselves", http.StatusBadRequest)
+		return uuid.Nil, false
+	}
+	_, err = cfg.db.GetUserById(context.Background(), targetID)
+	if err != nil {
+		ResponseError(w, err, "User not found", http.StatusNotFound)
+		return uuid.Nil, false
+	}
+	return targetID, true
+}
+
+func (cfg *apiConfig) handlerBlockUser(w http.ResponseWriter, r *http.Request) {
+
+	userID, err := cfg.authenticateRequest(r)
+	if err != nil {
+		ResponseError(w, err, "User not authenticated", http.StatusUnauthorized)
+		return
+	}
+
+	blockedID, ok := cfg.parseTargetUser(w, r, userID)
+	if !ok {
+		return
+	}
+
+	tx, err := cfg.dbConn.BeginTx(context.Background(), nil)
+	if err != nil {
+		ResponseError(w, err, "Error blocking user", http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.db.WithTx(tx)
+
+	_, err = qtx.CreateBlock(context.Background(), database.CreateBlockParams{
+		BlockerID: userID,
+		BlockedID: blockedID,
+		CreatedAt: time.Now(),
+	})
+	if err != nil {
+		ResponseError(w, err, "Error blocking user", http.StatusInternalServerError)
+		return
+	}
+
+	// A block ends any follow or follow request between the two users, in
+	// either direction
+	for _, pair := range [][2]uuid.UUID{{userID, blockedID}, {blockedID, userID}} {
+		_, err = removeFollow(context.Background(), qtx, pair[0], pair[1])
+		if err != nil {
+			ResponseError(w, err, "Error blocking user", http.StatusInternalServerError)
+			return
+		}
+		_, err = qtx.DeleteFollowRequest(context.Background(), database.DeleteFollowRequestParams{
+			RequesterID: pair[0],
+			TargetID:    pair[1],
+		})
+		if err != nil {
+			ResponseError(w, err, "Error blocking user", http.StatusInternalServerError)
+			return
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		ResponseError(w, err, "Error blocking user", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (cfg *apiConfig) handlerUnblockUser(w http.ResponseWriter, r *http.Request) {
+
+	userID, err := cfg.authenticateRequest(r)
+	if err != nil {
+		ResponseError(w, err, "User not authenticated", http.StatusUnauthorized)
+		return
+	}
+
+	blockedID, err := uuid.Parse(r.PathValue("userID"))
+	if err != nil {
+		ResponseError(w, err, "Error parsing user id", http.StatusBadRequest)
+		return
+	}
+
+	err = cfg.db.DeleteBlock(context.Background(), database.DeleteBlockParams{
+		BlockerID: userID,
+		BlockedID: blockedID,
+	})
+	if err != nil {
+		ResponseError(w, err, "Error unblocking user", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (cfg *apiConfig) handlerMuteUser(w http.ResponseWriter, r *http.Request) {
+
+	userID, err := cfg.authenticateRequest(r)
+	if err != nil {
+		ResponseError(w, err, "User not authenticated", http.StatusUnauthorized)
+		return
+	}
+
+	mutedID, ok := cfg.parseTargetUser(w, r, userID)
+	if !ok {
+		return
+	}
+
+	err = cfg.db.CreateMute(context.Background(), database.CreateMuteParams{
+		MuterID:   userID,
+		MutedID:   mutedID,
+		CreatedAt: time.Now(),
+	})
+	if err != nil {
+		ResponseError(w, err, "Error muting user", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (cfg *apiConfig) handlerUnmuteUser(w http.ResponseWriter, r *http.Request) {
+
+	userID, err := cfg.authenticateRequest(r)
+	if err != nil {
+		ResponseError(w, err, "User not authenticated", http.StatusUnauthorized)
+		return
+	}
+
+	mutedID, err := uuid.Parse(r.PathValue("userID"))
+	if err != nil {
+		ResponseError(w, err, "Error parsing user id", http.StatusBadRequest)
+		return
+	}
+
+	err = cfg.db.DeleteMute(context.Background(), database.DeleteMuteParams{
+		MuterID: userID,
+		MutedID: mutedID,
+	})
+	if err != nil {
+		ResponseError(w, err, "Error unmuting user", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jthughes/chirpynetwork/internal/database"
+	"github.com/jthughes/chirpynetwork/internal/pagination"
+)
+
+func (cfg *apiConfig) handlerBookmarkChirp(w http.ResponseWriter, r *http.Request) {
+
+	userID, err := cfg.authenticateRequest(r)
+	if err != nil {
+		ResponseError(w, err, "User not authenticated", http.StatusUnauthorized)
+		return
+	}
+
+	chirpID, err := uuid.Parse(r.PathValue("chirpID"))
+	if err != nil {
+		ResponseError(w, err, "Error parsing chirp id", http.StatusBadRequest)
+		return
+	}
+
+	_, err = cfg.getVisibleChirp(context.Background(), chirpID, uuid.NullUUID{UUID: userID, Valid: true})
+	if err != nil {
+		ResponseError(w, err, "Chirp ID not found", http.StatusNotFound)
+		return
+	}
+
+	// Bookmarking the same chirp again keeps its place in the list
+	_, err = cfg.db.CreateBookmark(context.Background(), database.CreateBookmarkParams{
+		UserID:    userID,
+		ChirpID:   chirpID,
+		CreatedAt: time.Now(),
+	})
+	if err != nil {
+		ResponseError(w, err, "Error bookmarking chirp", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (cfg *apiConfig) handlerUnbookmarkChirp(w http.ResponseWriter, r *http.Request) {
+
+	userID, err := cfg.authenticateRequest(r)
+	if err != nil {
+		ResponseError(w, err, "User not authenticated", http.StatusUnauthorized)
+		return
+	}
+
+	chirpID, err := uuid.Parse(r.PathValue("chirpID"))
+	if err != nil {
+		ResponseError(w, err, "Error parsing chirp id", http.StatusBadRequest)
+		return
+	}
+
+	_, err = cfg.db.DeleteBookmark(context.Background(), database.DeleteBookmarkParams{
+		UserID:  userID,
+		ChirpID: chirpID,
+	})
+	if err != nil {
+		ResponseError(w, err, "Error removing bookmark", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handlerGetBookmarks lists the caller's own bookmarks, most recently
+// bookmarked first. Bookmarks are private, so there is no way to read
+// another user's.
+func (cfg *apiConfig) handlerGetBookmarks(w http.ResponseWriter, r *http.Request) {
+
+	userID, err := cfg.authenticateRequest(r)
+	if err != nil {
+		ResponseError(w, err, "User not authenticated", http.StatusUnauthorized)
+		return
+	}
+
+	page, err := pagination.ParseRequest(r.URL.Query())
+	if err != nil {
+		ResponseError(w, err, "Invalid pagination parameters", http.StatusBadRequest)
+		return
+	}
+
+	// Fetch one extra row to find out whether there is a next page
+	rows, err := cfg.db.ListBookmarkedChirps(context.Background(), database.ListBookmarkedChirpsParams{
+		UserID:          userID,
+		HasCursor:       page.HasCursor,
+		CursorCreatedAt: page.Cursor.CreatedAt,
+		CursorID:        page.Cursor.ID,
+		RowLimit:        int32(page.Limit + 1),
+	})
+	if err != nil {
+		ResponseError(w, err, "Error retrieving bookmarks", http.StatusInternalServerError)
+		return
+	}
+	rows, next := pagination.Trim(rows, page.Limit, func(row database.ListBookmarkedChirpsRow) pagination.Cursor {
+		return pagination.Cursor{CreatedAt: row.BookmarkedAt, ID: row.Chirp.ID}
+	})
+
+	dbChirps := []database.Chirp{}
+	for _, row := range rows {
+		dbChirps = append(dbChirps, row.Chirp)
+	}
+	chirps, err := cfg.buildChirps(context.Background(), dbChirps, uuid.NullUUID{UUID: userID, Valid: true})
+	if err != nil {
+		ResponseError(w, err, "Error retrieving chirp details", http.StatusInternalServerError)
+		return
+	}
+
+	SetNextCursor(w, r, next)
+	data, err := json.Marshal(chirps)
+	SetJSONResponse(w, http.StatusOK, data, err)
+}
@@ -2,36 +2,246 @@ package main
 
 import (
 	"context"
+	"database/sql"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
-	"sort"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/jthughes/chirpynetwork/internal/database"
+	"github.com/jthughes/chirpynetwork/internal/hashtags"
+	"github.com/jthughes/chirpynetwork/internal/pagination"
+	"github.com/jthughes/chirpynetwork/internal/textlen"
 )
 
 type Chirp struct {
-	ID        uuid.UUID `json:"id"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
-	Body      string    `json:"body"`
-	UserId    uuid.UUID `json:"user_id"`
+	ID             uuid.UUID           `json:"id"`
+	CreatedAt      time.Time           `json:"created_at"`
+	UpdatedAt      time.Time           `json:"updated_at"`
+	Body           string              `json:"body"`
+	UserId         uuid.UUID           `json:"user_id"`
+	Edited         bool                `json:"edited"`
+	RevisionCount  int32               `json:"revision_count"`
+	InReplyTo      *uuid.UUID          `json:"in_reply_to"`
+	ConversationID uuid.UUID           `json:"conversation_id"`
+	QuoteOf        *uuid.UUID          `json:"quote_of"`
+	QuotedChirp    *Chirp              `json:"quoted_chirp,omitempty"`
+	RechirpedBy    *RechirpAttribution `json:"rechirped_by,omitempty"`
+	LikeCount      int32               `json:"like_count"`
+	LikedByMe      *bool               `json:"liked_by_me,omitempty"`
+	BookmarkedByMe *bool               `json:"bookmarked_by_me,omitempty"`
+	Media          []ChirpMedia        `json:"media"`
 }
 
 func dbChirpToChirp(dbChirp database.Chirp) Chirp {
-	return Chirp{
-		ID:        dbChirp.ID,
-		CreatedAt: dbChirp.CreatedAt,
-		UpdatedAt: dbChirp.UpdatedAt,
-		Body:      dbChirp.Body,
-		UserId:    dbChirp.UserID,
+	chirp := Chirp{
+		ID:             dbChirp.ID,
+		CreatedAt:      dbChirp.CreatedAt,
+		UpdatedAt:      dbChirp.UpdatedAt,
+		Body:           dbChirp.Body,
+		UserId:         dbChirp.UserID,
+		Edited:         dbChirp.RevisionCount > 0,
+		RevisionCount:  dbChirp.RevisionCount,
+		ConversationID: dbChirp.RootID,
+		LikeCount:      dbChirp.LikeCount,
+		Media:          []ChirpMedia{},
+	}
+	if dbChirp.ParentID.Valid {
+		chirp.InReplyTo = &dbChirp.ParentID.UUID
+	}
+	if dbChirp.QuoteOf.Valid {
+		chirp.QuoteOf = &dbChirp.QuoteOf.UUID
+	}
+	return chirp
+}
+
+// buildChirps converts chirps for a response, embedding their media and the
+// chirps they quote. Quoted chirps are embedded one level deep and are left
+// out if they have since been deleted or are hidden from the viewer. When
+// viewer is set, each chirp also reports whether the viewer has liked and
+// bookmarked it.
+func (cfg *apiConfig) buildChirps(ctx context.Context, dbChirps []database.Chirp, viewer uuid.NullUUID) ([]Chirp, error) {
+	chirpIDs := []uuid.UUID{}
+	quoteIDs := []uuid.UUID{}
+	for _, dbChirp := range dbChirps {
+		chirpIDs = append(chirpIDs, dbChirp.ID)
+		if dbChirp.QuoteOf.Valid {
+			quoteIDs = append(quoteIDs, dbChirp.QuoteOf.UUID)
+		}
+	}
+	var dbQuoted []database.Chirp
+	if len(quoteIDs) > 0 {
+		var err error
+		dbQuoted, err = cfg.db.GetVisibleChirpsByIDs(ctx, database.GetVisibleChirpsByIDsParams{
+			Ids:      quoteIDs,
+			ViewerID: viewer,
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	mediaChirpIDs := append([]uuid.UUID{}, chirpIDs...)
+	for _, item := range dbQuoted {
+		mediaChirpIDs = append(mediaChirpIDs, item.ID)
+	}
+	attached, err := cfg.getChirpMedia(ctx, mediaChirpIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	quoted := map[uuid.UUID]Chirp{}
+	for _, item := range dbQuoted {
+		quote := dbChirpToChirp(item)
+		if media, ok := attached[item.ID]; ok {
+			quote.Media = media
+		}
+		quoted[item.ID] = quote
+	}
+
+	liked := map[uuid.UUID]bool{}
+	if viewer.Valid && len(chirpIDs) > 0 {
+		likedIDs, err := cfg.db.GetLikedChirpIDs(ctx, database.GetLikedChirpIDsParams{
+			UserID:   viewer.UUID,
+			ChirpIds: chirpIDs,
+		})
+		if err != nil {
+			return nil, err
+		}
+		for _, id := range likedIDs {
+			liked[id] = true
+		}
+	}
+
+	bookmarked := map[uuid.UUID]bool{}
+	if viewer.Valid && len(chirpIDs) > 0 {
+		bookmarkedIDs, err := cfg.db.GetBookmarkedChirpIDs(ctx, database.GetBookmarkedChirpIDsParams{
+			UserID:   viewer.UUID,
+			ChirpIds: chirpIDs,
+		})
+		if err != nil {
+			return nil, err
+		}
+		for _, id := range bookmarkedIDs {
+			bookmarked[id] = true
+		}
+	}
+
+	out := []Chirp{}
+	for _, dbChirp := range dbChirps {
+		chirp := dbChirpToChirp(dbChirp)
+		if media, ok := attached[dbChirp.ID]; ok {
+			chirp.Media = media
+		}
+		if quote, ok := quoted[dbChirp.QuoteOf.UUID]; ok && dbChirp.QuoteOf.Valid {
+			chirp.QuotedChirp = &quote
+		}
+		if viewer.Valid {
+			likedByMe := liked[dbChirp.ID]
+			chirp.LikedByMe = &likedByMe
+			bookmarkedByMe := bookmarked[dbChirp.ID]
+			chirp.BookmarkedByMe = &bookmarkedByMe
+		}
+		out = append(out, chirp)
+	}
+	return out, nil
+}
+
+// getVisibleChirp fetches a chirp on behalf of viewer. A chirp whose author
+// blocked the viewer, or whose author is protected and not followed by the
+// viewer, is reported as missing, the same as a deleted one.
+func (cfg *apiConfig) getVisibleChirp(ctx context.Context, chirpID uuid.UUID, viewer uuid.NullUUID) (database.Chirp, error) {
+	dbChirp, err := cfg.db.GetChirpByID(ctx, chirpID)
+	if err != nil {
+		return database.Chirp{}, err
+	}
+	if viewer.Valid && viewer.UUID == dbChirp.UserID {
+		return dbChirp, nil
+	}
+
+	if viewer.Valid {
+		blocked, err := cfg.db.IsBlockedBy(ctx, database.IsBlockedByParams{
+			BlockerID: dbChirp.UserID,
+			BlockedID: viewer.UUID,
+		})
+		if err != nil {
+			return database.Chirp{}, err
+		}
+		if blocked {
+			return database.Chirp{}, sql.ErrNoRows
+		}
+	}
+
+	author, err := cfg.db.GetUserById(ctx, dbChirp.UserID)
+	if err != nil {
+		return database.Chirp{}, err
+	}
+	if !author.Protected {
+		return dbChirp, nil
+	}
+	if !viewer.Valid {
+		return database.Chirp{}, sql.ErrNoRows
+	}
+	following, err := cfg.db.IsFollowing(ctx, database.IsFollowingParams{
+		FollowerID: viewer.UUID,
+		FolloweeID: dbChirp.UserID,
+	})
+	if err != nil {
+		return database.Chirp{}, err
+	}
+	if !following {
+		return database.Chirp{}, sql.ErrNoRows
+	}
+	return dbChirp, nil
+}
+
+func (cfg *apiConfig) buildChirp(ctx context.Context, dbChirp database.Chirp, viewer uuid.NullUUID) (Chirp, error) {
+	chirps, err := cfg.buildChirps(ctx, []database.Chirp{dbChirp}, viewer)
+	if err != nil {
+		return Chirp{}, err
+	}
+	return chirps[0], nil
+}
+
+const (
+	chirpLengthLimit    = 140
+	chirpRedLengthLimit = 280
+)
+
+// ChirpTooLong is the detail sent with a 400 for a chirp over its author's
+// length limit.
+type ChirpTooLong struct {
+	Limit  int `json:"limit"`
+	Length int `json:"length"`
+}
+
+// checkChirpLength measures a chirp body in user-perceived characters, with
+// links weighted to a fixed length, against the limit of the author's plan.
+func checkChirpLength(body string, author database.User) *ChirpTooLong {
+	limit := chirpLengthLimit
+	if author.IsChirpyRed {
+		limit = chirpRedLengthLimit
+	}
+	length := textlen.Count(body)
+	if length > limit {
+		return &ChirpTooLong{Limit: limit, Length: length}
 	}
+	return nil
+}
+
+func ResponseChirpTooLong(w http.ResponseWriter, tooLong *ChirpTooLong) {
+	description := fmt.Sprintf("Chirp is too long: %d characters, limit is %d", tooLong.Length, tooLong.Limit)
+	ResponseErrorDetail(w, nil, description, http.StatusBadRequest, tooLong)
 }
 
 func (cfg *apiConfig) handlerNewChirp(w http.ResponseWriter, r *http.Request) {
 	type request struct {
-		Body string `json:"body"`
+		Body      string            `json:"body"`
+		InReplyTo *uuid.UUID        `json:"in_reply_to"`
+		QuoteOf   *uuid.UUID        `json:"quote_of"`
+		Media     []MediaAttachment `json:"media"`
 	}
 
 	userId, err := cfg.authenticateRequest(r)
@@ -54,65 +264,232 @@ func (cfg *apiConfig) handlerNewChirp(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if len(test.Body) > 140 {
-		ResponseError(w, nil, "Chirp is too long", http.StatusBadRequest)
+	if tooLong := checkChirpLength(test.Body, user); tooLong != nil {
+		ResponseChirpTooLong(w, tooLong)
+		return
+	}
+
+	err = checkMediaAttachments(test.Media)
+	if err != nil {
+		ResponseError(w, err, fmt.Sprintf("Invalid media: %s", err), http.StatusBadRequest)
 		return
 	}
 
-	dbChirp, err := cfg.db.CreateChirp(context.Background(), database.CreateChirpParams{
-		ID:        uuid.New(),
+	moderated, err := cfg.moderateChirp(context.Background(), test.Body)
+	if err != nil {
+		ResponseError(w, err, "Error applying content policy", http.StatusInternalServerError)
+		return
+	}
+	if moderated.Rejected != nil {
+		ResponsePolicyRejection(w, moderated.Rejected)
+		return
+	}
+	// Masking replaces matches with a fixed mask, which can be longer
+	if tooLong := checkChirpLength(moderated.Body, user); tooLong != nil {
+		ResponseChirpTooLong(w, tooLong)
+		return
+	}
+
+	// Replies join their parent's conversation, everything else starts a new one
+	viewer := uuid.NullUUID{UUID: user.ID, Valid: true}
+	chirpID := uuid.New()
+	parentID := uuid.NullUUID{}
+	rootID := chirpID
+	if test.InReplyTo != nil {
+		parent, err := cfg.getVisibleChirp(context.Background(), *test.InReplyTo, viewer)
+		if err != nil {
+			ResponseError(w, err, "Chirp being replied to not found", http.StatusBadRequest)
+			return
+		}
+		blocked, err := cfg.db.BlockExistsBetween(context.Background(), database.BlockExistsBetweenParams{
+			UserID:  user.ID,
+			OtherID: parent.UserID,
+		})
+		if err != nil {
+			ResponseError(w, err, "Unable to create chirp", http.StatusInternalServerError)
+			return
+		}
+		if blocked {
+			ResponseError(w, nil, "Cannot reply to this user", http.StatusForbidden)
+			return
+		}
+		parentID = uuid.NullUUID{UUID: parent.ID, Valid: true}
+		rootID = parent.RootID
+	}
+
+	quoteOf := uuid.NullUUID{}
+	if test.QuoteOf != nil {
+		quoted, err := cfg.getVisibleChirp(context.Background(), *test.QuoteOf, viewer)
+		if err != nil {
+			ResponseError(w, err, "Quoted chirp not found", http.StatusBadRequest)
+			return
+		}
+		quoteOf = uuid.NullUUID{UUID: quoted.ID, Valid: true}
+	}
+
+	tx, err := cfg.dbConn.BeginTx(context.Background(), nil)
+	if err != nil {
+		ResponseError(w, err, "Unable to create chirp", http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.db.WithTx(tx)
+
+	dbChirp, err := qtx.CreateChirp(context.Background(), database.CreateChirpParams{
+		ID:        chirpID,
 		CreatedAt: time.Now(),
 		UpdatedAt: time.Now(),
-		Body:      test.Body,
+		Body:      moderated.Body,
 		UserID:    user.ID,
+		ParentID:  parentID,
+		RootID:    rootID,
+		QuoteOf:   quoteOf,
+		FannedOut: user.FollowerCount < cfg.fanOutLimit,
 	})
 	if err != nil {
 		ResponseError(w, nil, "Unable to create chirp", http.StatusInternalServerError)
 		return
 	}
 
-	chirp := dbChirpToChirp(dbChirp)
+	err = attachMedia(context.Background(), qtx, dbChirp.ID, user.ID, test.Media)
+	if errors.Is(err, errMediaUnavailable) {
+		ResponseError(w, err, "Media not found", http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		ResponseError(w, err, "Error attaching media", http.StatusInternalServerError)
+		return
+	}
+
+	err = hashtags.Replace(context.Background(), qtx, dbChirp.ID, dbChirp.Body)
+	if err != nil {
+		ResponseError(w, err, "Error storing chirp tags", http.StatusInternalServerError)
+		return
+	}
+
+	err = storeChirpFlags(context.Background(), qtx, dbChirp.ID, moderated.Flags)
+	if err != nil {
+		ResponseError(w, err, "Error flagging chirp for review", http.StatusInternalServerError)
+		return
+	}
+
+	// Chirps from high-follower accounts are merged in when timelines are
+	// read. The choice is kept with the chirp, so it is still found the same
+	// way if the author's follower count changes.
+	if dbChirp.FannedOut {
+		err = qtx.FanOutChirp(context.Background(), database.FanOutChirpParams{
+			ChirpID:   dbChirp.ID,
+			CreatedAt: dbChirp.CreatedAt,
+			AuthorID:  user.ID,
+		})
+		if err != nil {
+			ResponseError(w, err, "Error delivering chirp to followers", http.StatusInternalServerError)
+			return
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		ResponseError(w, err, "Unable to create chirp", http.StatusInternalServerError)
+		return
+	}
+
+	chirp, err := cfg.buildChirp(context.Background(), dbChirp, viewer)
+	if err != nil {
+		ResponseError(w, err, "Error retrieving chirp details", http.StatusInternalServerError)
+		return
+	}
 	data, err := json.Marshal(chirp)
 	SetJSONResponse(w, http.StatusCreated, data, err)
 }
 
+func chirpCursor(dbChirp database.Chirp) pagination.Cursor {
+	return pagination.Cursor{
+		CreatedAt: dbChirp.CreatedAt,
+		ID:        dbChirp.ID,
+	}
+}
+
 func (cfg *apiConfig) handlerGetAllChirps(w http.ResponseWriter, r *http.Request) {
-	author := r.URL.Query().Get("author_id")
+	query := r.URL.Query()
 
-	var chirps []database.Chirp
-	var err error
-	if author == "" {
-		chirps, err = cfg.db.GetAllChirps(context.Background())
+	page, err := pagination.ParseRequest(query)
+	if err != nil {
+		ResponseError(w, err, "Invalid pagination parameters", http.StatusBadRequest)
+		return
+	}
+
+	var dbChirps []database.Chirp
+	var next *pagination.Cursor
+	var rechirps []*RechirpAttribution
+	viewer := cfg.optionalViewer(r)
+	desc := query.Get("sort") == "desc"
+	if author := query.Get("author_id"); author != "" {
+		authorID, err := uuid.Parse(author)
 		if err != nil {
-			ResponseError(w, err, "Error retrieving chirps", http.StatusNotFound)
+			ResponseError(w, err, "Error parsing author id", http.StatusBadRequest)
 			return
 		}
-	} else {
-		authorID, err := uuid.Parse(author)
+		dbChirps, rechirps, next, err = cfg.getAuthorTimeline(context.Background(), authorID, viewer, page, desc)
 		if err != nil {
-			ResponseError(w, err, "Error parsing author id", http.StatusBadRequest)
+			ResponseError(w, err, "Error retrieving chirps", http.StatusNotFound)
+			return
 		}
-		chirps, err = cfg.db.GetAllChirpsFromAuthor(context.Background(), authorID)
+	} else {
+		dbChirps, next, err = cfg.getChirpsPage(context.Background(), viewer, uuid.NullUUID{}, page, desc)
 		if err != nil {
 			ResponseError(w, err, "Error retrieving chirps", http.StatusNotFound)
 			return
 		}
 	}
 
-	sortDir := r.URL.Query().Get("sort")
-	if sortDir == "desc" {
-		sort.Slice(chirps, func(i, j int) bool { return chirps[i].CreatedAt.After(chirps[j].CreatedAt) })
+	out, err := cfg.buildChirps(context.Background(), dbChirps, viewer)
+	if err != nil {
+		ResponseError(w, err, "Error retrieving chirp details", http.StatusInternalServerError)
+		return
 	}
-
-	out := []Chirp{}
-	for _, item := range chirps {
-		out = append(out, dbChirpToChirp(item))
+	for i, attribution := range rechirps {
+		out[i].RechirpedBy = attribution
 	}
 
+	SetNextCursor(w, r, next)
 	data, err := json.Marshal(out)
 	SetJSONResponse(w, http.StatusOK, data, err)
 }
 
+// getChirpsPage fetches one page of the global chirp feed as seen by viewer,
+// leaving out the chirps hidden from them by blocks and mutes. When listID is
+// set, only chirps from the members of that list are included.
+func (cfg *apiConfig) getChirpsPage(ctx context.Context, viewer uuid.NullUUID, listID uuid.NullUUID, page pagination.Request, desc bool) ([]database.Chirp, *pagination.Cursor, error) {
+	// Fetch one extra row to find out whether there is a next page
+	var dbChirps []database.Chirp
+	var err error
+	if desc {
+		dbChirps, err = cfg.db.ListChirpsDesc(ctx, database.ListChirpsDescParams{
+			HasCursor:       page.HasCursor,
+			CursorCreatedAt: page.Cursor.CreatedAt,
+			CursorID:        page.Cursor.ID,
+			ViewerID:        viewer,
+			ListID:          listID,
+			RowLimit:        int32(page.Limit + 1),
+		})
+	} else {
+		dbChirps, err = cfg.db.ListChirpsAsc(ctx, database.ListChirpsAscParams{
+			HasCursor:       page.HasCursor,
+			CursorCreatedAt: page.Cursor.CreatedAt,
+			CursorID:        page.Cursor.ID,
+			ViewerID:        viewer,
+			ListID:          listID,
+			RowLimit:        int32(page.Limit + 1),
+		})
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	dbChirps, next := pagination.Trim(dbChirps, page.Limit, chirpCursor)
+	return dbChirps, next, nil
+}
+
 func (cfg *apiConfig) handlerGetChirpByID(w http.ResponseWriter, r *http.Request) {
 
 	requestChirpID, err := uuid.Parse(r.PathValue("chirpID"))
@@ -121,13 +498,17 @@ func (cfg *apiConfig) handlerGetChirpByID(w http.ResponseWriter, r *http.Request
 		return
 	}
 
-	dbChirp, err := cfg.db.GetChirpByID(context.Background(), requestChirpID)
+	dbChirp, err := cfg.getVisibleChirp(context.Background(), requestChirpID, cfg.optionalViewer(r))
 	if err != nil {
 		ResponseError(w, err, "Chirp ID not found", http.StatusNotFound)
 		return
 	}
 
-	chirp := dbChirpToChirp(dbChirp)
+	chirp, err := cfg.buildChirp(context.Background(), dbChirp, cfg.optionalViewer(r))
+	if err != nil {
+		ResponseError(w, err, "Error retrieving chirp details", http.StatusInternalServerError)
+		return
+	}
 	data, err := json.Marshal(chirp)
 	SetJSONResponse(w, http.StatusOK, data, err)
 }
@@ -157,6 +538,7 @@ func (cfg *apiConfig) handlerDeleteChirp(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
+	// The chirp's media is released and garbage collected later
 	err = cfg.db.DeleteChirp(context.Background(), chirpID)
 	if err != nil {
 		ResponseError(w, err, "Error deleting chirp", http.StatusInternalServerError)
@@ -165,3 +547,129 @@ func (cfg *apiConfig) handlerDeleteChirp(w http.ResponseWriter, r *http.Request)
 
 	w.WriteHeader(http.StatusNoContent)
 }
+
+func (cfg *apiConfig) handlerEditChirp(w http.ResponseWriter, r *http.Request) {
+	type request struct {
+		Body string `json:"body"`
+	}
+
+	userID, err := cfg.authenticateRequest(r)
+	if err != nil {
+		ResponseError(w, err, "User not authenticated", http.StatusUnauthorized)
+		return
+	}
+
+	chirpID, err := uuid.Parse(r.PathValue("chirpID"))
+	if err != nil {
+		ResponseError(w, err, "Error parsing chirp id", http.StatusBadRequest)
+		return
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	test := request{}
+	err = decoder.Decode(&test)
+	if err != nil {
+		ResponseError(w, err, "Error decoding chirp", http.StatusInternalServerError)
+		return
+	}
+
+	user, err := cfg.db.GetUserById(context.Background(), userID)
+	if err != nil {
+		ResponseError(w, nil, "User does not exist", http.StatusBadRequest)
+		return
+	}
+
+	if tooLong := checkChirpLength(test.Body, user); tooLong != nil {
+		ResponseChirpTooLong(w, tooLong)
+		return
+	}
+
+	moderated, err := cfg.moderateChirp(context.Background(), test.Body)
+	if err != nil {
+		ResponseError(w, err, "Error applying content policy", http.StatusInternalServerError)
+		return
+	}
+	if moderated.Rejected != nil {
+		ResponsePolicyRejection(w, moderated.Rejected)
+		return
+	}
+	// Masking replaces matches with a fixed mask, which can be longer
+	if tooLong := checkChirpLength(moderated.Body, user); tooLong != nil {
+		ResponseChirpTooLong(w, tooLong)
+		return
+	}
+
+	tx, err := cfg.dbConn.BeginTx(context.Background(), nil)
+	if err != nil {
+		ResponseError(w, err, "Error editing chirp", http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.db.WithTx(tx)
+
+	// Lock the chirp so concurrent edits can't lose a revision
+	dbChirp, err := qtx.GetChirpForUpdate(context.Background(), chirpID)
+	if err != nil {
+		ResponseError(w, err, "Chirp ID not found", http.StatusNotFound)
+		return
+	}
+
+	if dbChirp.UserID != userID {
+		ResponseError(w, nil, "User not authorized", http.StatusForbidden)
+		return
+	}
+
+	if time.Since(dbChirp.CreatedAt) > cfg.editWindow {
+		ResponseError(w, nil, "Edit window has closed", http.StatusForbidden)
+		return
+	}
+
+	now := time.Now()
+	_, err = qtx.CreateChirpRevision(context.Background(), database.CreateChirpRevisionParams{
+		ID:         uuid.New(),
+		ChirpID:    dbChirp.ID,
+		Body:       dbChirp.Body,
+		CreatedAt:  dbChirp.UpdatedAt,
+		ReplacedAt: now,
+	})
+	if err != nil {
+		ResponseError(w, err, "Error storing chirp revision", http.StatusInternalServerError)
+		return
+	}
+
+	dbChirp, err = qtx.UpdateChirpBody(context.Background(), database.UpdateChirpBodyParams{
+		ID:        dbChirp.ID,
+		Body:      moderated.Body,
+		UpdatedAt: now,
+	})
+	if err != nil {
+		ResponseError(w, err, "Error editing chirp", http.StatusInternalServerError)
+		return
+	}
+
+	err = hashtags.Replace(context.Background(), qtx, dbChirp.ID, dbChirp.Body)
+	if err != nil {
+		ResponseError(w, err, "Error storing chirp tags", http.StatusInternalServerError)
+		return
+	}
+
+	err = storeChirpFlags(context.Background(), qtx, dbChirp.ID, moderated.Flags)
+	if err != nil {
+		ResponseError(w, err, "Error flagging chirp for review", http.StatusInternalServerError)
+		return
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		ResponseError(w, err, "Error editing chirp", http.StatusInternalServerError)
+		return
+	}
+
+	chirp, err := cfg.buildChirp(context.Background(), dbChirp, uuid.NullUUID{UUID: userID, Valid: true})
+	if err != nil {
+		ResponseError(w, err, "Error retrieving chirp details", http.StatusInternalServerError)
+		return
+	}
+	data, err := json.Marshal(chirp)
+	SetJSONResponse(w, http.StatusOK, data, err)
+}
@@ -0,0 +1,72 @@
+// Command backfill-tags extracts the hashtags of every existing chirp. It is
+// safe to run more than once.
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+
+	"github.com/joho/godotenv"
+	"github.com/jthughes/chirpynetwork/internal/database"
+	"github.com/jthughes/chirpynetwork/internal/hashtags"
+	_ "github.com/lib/pq"
+)
+
+const batchSize = 500
+
+func main() {
+	godotenv.Load()
+	db, err := sql.Open("postgres", os.Getenv("DB_URL"))
+	if err != nil {
+		fmt.Printf("Unable to open connection to database: %s\n", err)
+		os.Exit(1)
+	}
+
+	count, err := backfill(context.Background(), db)
+	if err != nil {
+		fmt.Printf("Backfill stopped after %d chirps: %s\n", count, err)
+		os.Exit(1)
+	}
+	fmt.Printf("Backfilled tags for %d chirps\n", count)
+}
+
+func backfill(ctx context.Context, db *sql.DB) (int, error) {
+	queries := database.New(db)
+	count := 0
+	params := database.ListAllChirpsForBackfillParams{RowLimit: batchSize}
+	for {
+		chirps, err := queries.ListAllChirpsForBackfill(ctx, params)
+		if err != nil {
+			return count, err
+		}
+		for _, chirp := range chirps {
+			err = replaceTags(ctx, db, queries, chirp)
+			if err != nil {
+				return count, err
+			}
+			count++
+		}
+		if len(chirps) < batchSize {
+			return count, nil
+		}
+		last := chirps[len(chirps)-1]
+		params.HasCursor = true
+		params.CursorCreatedAt = last.CreatedAt
+		params.CursorID = last.ID
+	}
+}
+
+func replaceTags(ctx context.Context, db *sql.DB, queries *database.Queries, chirp database.Chirp) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	err = hashtags.Replace(ctx, queries.WithTx(tx), chirp.ID, chirp.Body)
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,376 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jthughes/chirpynetwork/internal/database"
+	"github.com/jthughes/chirpynetwork/internal/moderation"
+	"github.com/jthughes/chirpynetwork/internal/pagination"
+)
+
+type ContentRule struct {
+	ID        uuid.UUID         `json:"id"`
+	CreatedAt time.Time         `json:"created_at"`
+	UpdatedAt time.Time         `json:"updated_at"`
+	Position  int32             `json:"position"`
+	Name      string            `json:"name"`
+	Kind      moderation.Kind   `json:"kind"`
+	Action    moderation.Action `json:"action"`
+	Config    moderation.Config `json:"config"`
+	Enabled   bool              `json:"enabled"`
+}
+
+func dbContentRuleToContentRule(dbRule database.ContentRule) (ContentRule, error) {
+	config := moderation.Config{}
+	err := json.Unmarshal(dbRule.Config, &config)
+	if err != nil {
+		return ContentRule{}, fmt.Errorf("rule %s has invalid config: %v", dbRule.ID, err)
+	}
+	return ContentRule{
+		ID:        dbRule.ID,
+		CreatedAt: dbRule.CreatedAt,
+		UpdatedAt: dbRule.UpdatedAt,
+		Position:  dbRule.Position,
+		Name:      dbRule.Name,
+		Kind:      moderation.Kind(dbRule.Kind),
+		Action:    moderation.Action(dbRule.Action),
+		Config:    config,
+		Enabled:   dbRule.Enabled,
+	}, nil
+}
+
+func (rule ContentRule) toModerationRule() moderation.Rule {
+	return moderation.Rule{
+		ID:     rule.ID,
+		Name:   rule.Name,
+		Kind:   rule.Kind,
+		Action: rule.Action,
+		Config: rule.Config,
+	}
+}
+
+type ChirpFlag struct {
+	ID        uuid.UUID  `json:"id"`
+	CreatedAt time.Time  `json:"created_at"`
+	ChirpID   uuid.UUID  `json:"chirp_id"`
+	RuleID    *uuid.UUID `json:"rule_id"`
+	RuleName  string     `json:"rule_name"`
+	Reason    string     `json:"reason"`
+}
+
+func dbChirpFlagToChirpFlag(dbFlag database.ChirpFlag) ChirpFlag {
+	flag := ChirpFlag{
+		ID:        dbFlag.ID,
+		CreatedAt: dbFlag.CreatedAt,
+		ChirpID:   dbFlag.ChirpID,
+		RuleName:  dbFlag.RuleName,
+		Reason:    dbFlag.Reason,
+	}
+	if dbFlag.RuleID.Valid {
+		flag.RuleID = &dbFlag.RuleID.UUID
+	}
+	return flag
+}
+
+// moderateChirp runs a chirp body through the enabled content rules.
+func (cfg *apiConfig) moderateChirp(ctx context.Context, body string) (moderation.Result, error) {
+	dbRules, err := cfg.db.ListEnabledContentRules(ctx)
+	if err != nil {
+		return moderation.Result{}, err
+	}
+	rules := []moderation.Rule{}
+	for _, dbRule := range dbRules {
+		rule, err := dbContentRuleToContentRule(dbRule)
+		if err != nil {
+			return moderation.Result{}, err
+		}
+		rules = append(rules, rule.toModerationRule())
+	}
+	pipeline, err := moderation.NewPipeline(rules)
+	if err != nil {
+		return moderation.Result{}, err
+	}
+	return pipeline.Apply(body), nil
+}
+
+// storeChirpFlags queues a chirp for review for each rule that flagged it.
+func storeChirpFlags(ctx context.Context, db *database.Queries, chirpID uuid.UUID, flags []moderation.Violation) error {
+	for _, flag := range flags {
+		err := db.CreateChirpFlag(ctx, database.CreateChirpFlagParams{
+			ID:        uuid.New(),
+			CreatedAt: time.Now(),
+			ChirpID:   chirpID,
+			RuleID:    uuid.NullUUID{UUID: flag.RuleID, Valid: true},
+			RuleName:  flag.RuleName,
+			Reason:    flag.Reason,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ResponsePolicyRejection reports which content rule rejected a chirp.
+func ResponsePolicyRejection(w http.ResponseWriter, violation *moderation.Violation) {
+	description := fmt.Sprintf("Chirp rejected by content rule %q: %s", violation.RuleName, violation.Reason)
+	ResponseErrorDetail(w, nil, description, http.StatusBadRequest, violation)
+}
+
+// decodeContentRule reads and validates a content rule from a request body.
+func decodeContentRule(r *http.Request) (ContentRule, error) {
+	type request struct {
+		Position int32             `json:"position"`
+		Name     string            `json:"name"`
+		Kind     moderation.Kind   `json:"kind"`
+		Action   moderation.Action `json:"action"`
+		Config   moderation.Config `json:"config"`
+		Enabled  *bool             `json:"enabled"`
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	req := request{}
+	err := decoder.Decode(&req)
+	if err != nil {
+		return ContentRule{}, fmt.Errorf("invalid request body: %v", err)
+	}
+	if req.Name == "" {
+		return ContentRule{}, fmt.Errorf("rules need a name")
+	}
+
+	rule := ContentRule{
+		Position: req.Position,
+		Name:     req.Name,
+		Kind:     req.Kind,
+		Action:   req.Action,
+		Config:   req.Config,
+		Enabled:  req.Enabled == nil || *req.Enabled,
+	}
+	err = rule.toModerationRule().Validate()
+	if err != nil {
+		return ContentRule{}, err
+	}
+	return rule, nil
+}
+
+func (cfg *apiConfig) requireAdmin(w http.ResponseWriter, r *http.Request) bool {
+	_, isAdmin, err := cfg.authenticateAdmin(r)
+	if err != nil {
+		ResponseError(w, err, "User not authenticated", http.StatusUnauthorized)
+		return false
+	}
+	if !isAdmin {
+		ResponseError(w, nil, "User not authorized", http.StatusForbidden)
+		return false
+	}
+	return true
+}
+
+func (cfg *apiConfig) handlerListContentRules(w http.ResponseWriter, r *http.Request) {
+	if !cfg.requireAdmin(w, r) {
+		return
+	}
+
+	dbRules, err := cfg.db.ListContentRules(context.Background())
+	if err != nil {
+		ResponseError(w, err, "Error retrieving content rules", http.StatusInternalServerError)
+		return
+	}
+
+	out := []ContentRule{}
+	for _, dbRule := range dbRules {
+		rule, err := dbContentRuleToContentRule(dbRule)
+		if err != nil {
+			ResponseError(w, err, "Error retrieving content rules", http.StatusInternalServerError)
+			return
+		}
+		out = append(out, rule)
+	}
+
+	data, err := json.Marshal(out)
+	SetJSONResponse(w, http.StatusOK, data, err)
+}
+
+func (cfg *apiConfig) handlerCreateContentRule(w http.ResponseWriter, r *http.Request) {
+	if !cfg.requireAdmin(w, r) {
+		return
+	}
+
+	rule, err := decodeContentRule(r)
+	if err != nil {
+		ResponseError(w, err, "Invalid content rule", http.StatusBadRequest)
+		return
+	}
+
+	config, err := json.Marshal(rule.Config)
+	if err != nil {
+		ResponseError(w, err, "Error encoding rule config", http.StatusInternalServerError)
+		return
+	}
+
+	dbRule, err := cfg.db.CreateContentRule(context.Background(), database.CreateContentRuleParams{
+		ID:        uuid.New(),
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+		Position:  rule.Position,
+		Name:      rule.Name,
+		Kind:      string(rule.Kind),
+		Action:    string(rule.Action),
+		Config:    config,
+		Enabled:   rule.Enabled,
+	})
+	if err != nil {
+		ResponseError(w, err, "Error creating content rule", http.StatusInternalServerError)
+		return
+	}
+
+	rule, err = dbContentRuleToContentRule(dbRule)
+	if err != nil {
+		ResponseError(w, err, "Error creating content rule", http.StatusInternalServerError)
+		return
+	}
+	data, err := json.Marshal(rule)
+	SetJSONResponse(w, http.StatusCreated, data, err)
+}
+
+func (cfg *apiConfig) handlerUpdateContentRule(w http.ResponseWriter, r *http.Request) {
+	if !cfg.requireAdmin(w, r) {
+		return
+	}
+
+	ruleID, err := uuid.Parse(r.PathValue("ruleID"))
+	if err != nil {
+		ResponseError(w, err, "Error parsing rule id", http.StatusBadRequest)
+		return
+	}
+
+	rule, err := decodeContentRule(r)
+	if err != nil {
+		ResponseError(w, err, "Invalid content rule", http.StatusBadRequest)
+		return
+	}
+
+	config, err := json.Marshal(rule.Config)
+	if err != nil {
+		ResponseError(w, err, "Error encoding rule config", http.StatusInternalServerError)
+		return
+	}
+
+	dbRule, err := cfg.db.UpdateContentRule(context.Background(), database.UpdateContentRuleParams{
+		ID:        ruleID,
+		Position:  rule.Position,
+		Name:      rule.Name,
+		Kind:      string(rule.Kind),
+		Action:    string(rule.Action),
+		Config:    config,
+		Enabled:   rule.Enabled,
+		UpdatedAt: time.Now(),
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		ResponseError(w, err, "Content rule not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		ResponseError(w, err, "Error updating content rule", http.StatusInternalServerError)
+		return
+	}
+
+	rule, err = dbContentRuleToContentRule(dbRule)
+	if err != nil {
+		ResponseError(w, err, "Error updating content rule", http.StatusInternalServerError)
+		return
+	}
+	data, err := json.Marshal(rule)
+	SetJSONResponse(w, http.StatusOK, data, err)
+}
+
+func (cfg *apiConfig) handlerDeleteContentRule(w http.ResponseWriter, r *http.Request) {
+	if !cfg.requireAdmin(w, r) {
+		return
+	}
+
+	ruleID, err := uuid.Parse(r.PathValue("ruleID"))
+	if err != nil {
+		ResponseError(w, err, "Error parsing rule id", http.StatusBadRequest)
+		return
+	}
+
+	deleted, err := cfg.db.DeleteContentRule(context.Background(), ruleID)
+	if err != nil {
+		ResponseError(w, err, "Error deleting content rule", http.StatusInternalServerError)
+		return
+	}
+	if deleted == 0 {
+		ResponseError(w, nil, "Content rule not found", http.StatusNotFound)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (cfg *apiConfig) handlerListChirpFlags(w http.ResponseWriter, r *http.Request) {
+	if !cfg.requireAdmin(w, r) {
+		return
+	}
+
+	page, err := pagination.ParseRequest(r.URL.Query())
+	if err != nil {
+		ResponseError(w, err, "Invalid pagination parameters", http.StatusBadRequest)
+		return
+	}
+
+	// Fetch one extra row to find out whether there is a next page
+	dbFlags, err := cfg.db.ListChirpFlags(context.Background(), database.ListChirpFlagsParams{
+		HasCursor:       page.HasCursor,
+		CursorCreatedAt: page.Cursor.CreatedAt,
+		CursorID:        page.Cursor.ID,
+		RowLimit:        int32(page.Limit + 1),
+	})
+	if err != nil {
+		ResponseError(w, err, "Error retrieving flagged chirps", http.StatusInternalServerError)
+		return
+	}
+	dbFlags, next := pagination.Trim(dbFlags, page.Limit, func(flag database.ChirpFlag) pagination.Cursor {
+		return pagination.Cursor{CreatedAt: flag.CreatedAt, ID: flag.ID}
+	})
+
+	out := []ChirpFlag{}
+	for _, item := range dbFlags {
+		out = append(out, dbChirpFlagToChirpFlag(item))
+	}
+
+	SetNextCursor(w, r, next)
+	data, err := json.Marshal(out)
+	SetJSONResponse(w, http.StatusOK, data, err)
+}
+
+func (cfg *apiConfig) handlerDismissChirpFlag(w http.ResponseWriter, r *http.Request) {
+	if !cfg.requireAdmin(w, r) {
+		return
+	}
+
+	flagID, err := uuid.Parse(r.PathValue("flagID"))
+	if err != nil {
+		ResponseError(w, err, "Error parsing flag id", http.StatusBadRequest)
+		return
+	}
+
+	deleted, err := cfg.db.DeleteChirpFlag(context.Background(), flagID)
+	if err != nil {
+		ResponseError(w, err, "Error dismissing flag", http.StatusInternalServerError)
+		return
+	}
+	if deleted == 0 {
+		ResponseError(w, nil, "Flag not found", http.StatusNotFound)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,152 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jthughes/chirpynetwork/internal/database"
+	"github.com/jthughes/chirpynetwork/internal/pagination"
+)
+
+type FollowRequest struct {
+	RequesterID uuid.UUID `json:"requester_id"`
+	TargetID    uuid.UUID `json:"target_id"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+func dbFollowRequestToFollowRequest(dbRequest database.FollowRequest) FollowRequest {
+	return FollowRequest{
+		RequesterID: dbRequest.RequesterID,
+		TargetID:    dbRequest.TargetID,
+		CreatedAt:   dbRequest.CreatedAt,
+	}
+}
+
+func (cfg *apiConfig) handlerListFollowRequests(w http.ResponseWriter, r *http.Request) {
+
+	userID, err := cfg.authenticateRequest(r)
+	if err != nil {
+		ResponseError(w, err, "User not authenticated", http.StatusUnauthorized)
+		return
+	}
+
+	page, err := pagination.ParseRequest(r.URL.Query())
+	if err != nil {
+		ResponseError(w, err, "Invalid pagination parameters", http.StatusBadRequest)
+		return
+	}
+
+	// Fetch one extra row to find out whether there is a next page
+	requests, err := cfg.db.ListFollowRequests(context.Background(), database.ListFollowRequestsParams{
+		TargetID:        userID,
+		HasCursor:       page.HasCursor,
+		CursorCreatedAt: page.Cursor.CreatedAt,
+		CursorID:        page.Cursor.ID,
+		RowLimit:        int32(page.Limit + 1),
+	})
+	if err != nil {
+		ResponseError(w, err, "Error retrieving follow requests", http.StatusInternalServerError)
+		return
+	}
+	requests, next := pagination.Trim(requests, page.Limit, func(request database.FollowRequest) pagination.Cursor {
+		return pagination.Cursor{CreatedAt: request.CreatedAt, ID: request.RequesterID}
+	})
+
+	out := []FollowRequest{}
+	for _, item := range requests {
+		out = append(out, dbFollowRequestToFollowRequest(item))
+	}
+
+	SetNextCursor(w, r, next)
+	data, err := json.Marshal(out)
+	SetJSONResponse(w, http.StatusOK, data, err)
+}
+
+func (cfg *apiConfig) handlerApproveFollowRequest(w http.ResponseWriter, r *http.Request) {
+
+	userID, err := cfg.authenticateRequest(r)
+	if err != nil {
+		ResponseError(w, err, "User not authenticated", http.StatusUnauthorized)
+		return
+	}
+
+	requesterID, err := uuid.Parse(r.PathValue("userID"))
+	if err != nil {
+		ResponseError(w, err, "Error parsing user id", http.StatusBadRequest)
+		return
+	}
+
+	user, err := cfg.db.GetUserById(context.Background(), userID)
+	if err != nil {
+		ResponseError(w, err, "User does not exist", http.StatusBadRequest)
+		return
+	}
+
+	tx, err := cfg.dbConn.BeginTx(context.Background(), nil)
+	if err != nil {
+		ResponseError(w, err, "Error approving follow request", http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.db.WithTx(tx)
+
+	deleted, err := qtx.DeleteFollowRequest(context.Background(), database.DeleteFollowRequestParams{
+		RequesterID: requesterID,
+		TargetID:    userID,
+	})
+	if err != nil {
+		ResponseError(w, err, "Error approving follow request", http.StatusInternalServerError)
+		return
+	}
+	if deleted == 0 {
+		ResponseError(w, nil, "Follow request not found", http.StatusNotFound)
+		return
+	}
+
+	_, err = addFollow(context.Background(), qtx, requesterID, user)
+	if err != nil {
+		ResponseError(w, err, "Error approving follow request", http.StatusInternalServerError)
+		return
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		ResponseError(w, err, "Error approving follow request", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (cfg *apiConfig) handlerDenyFollowRequest(w http.ResponseWriter, r *http.Request) {
+
+	userID, err := cfg.authenticateRequest(r)
+	if err != nil {
+		ResponseError(w, err, "User not authenticated", http.StatusUnauthorized)
+		return
+	}
+
+	requesterID, err := uuid.Parse(r.PathValue("userID"))
+	if err != nil {
+		ResponseError(w, err, "Error parsing user id", http.StatusBadRequest)
+		return
+	}
+
+	deleted, err := cfg.db.DeleteFollowRequest(context.Background(), database.DeleteFollowRequestParams{
+		RequesterID: requesterID,
+		TargetID:    userID,
+	})
+	if err != nil {
+		ResponseError(w, err, "Error denying follow request", http.StatusInternalServerError)
+		return
+	}
+	if deleted == 0 {
+		ResponseError(w, nil, "Follow request not found", http.StatusNotFound)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,308 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jthughes/chirpynetwork/internal/database"
+	"github.com/jthughes/chirpynetwork/internal/pagination"
+	"github.com/jthughes/chirpynetwork/internal/timeline"
+)
+
+type Follow struct {
+	FollowerID uuid.UUID `json:"follower_id"`
+	FolloweeID uuid.UUID `json:"followee_id"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+func dbFollowToFollow(dbFollow database.Follow) Follow {
+	return Follow{
+		FollowerID: dbFollow.FollowerID,
+		FolloweeID: dbFollow.FolloweeID,
+		CreatedAt:  dbFollow.CreatedAt,
+	}
+}
+
+func (cfg *apiConfig) handlerFollowUser(w http.ResponseWriter, r *http.Request) {
+
+	followerID, err := cfg.authenticateRequest(r)
+	if err != nil {
+		ResponseError(w, err, "User not authenticated", http.StatusUnauthorized)
+		return
+	}
+
+	followeeID, err := uuid.Parse(r.PathValue("userID"))
+	if err != nil {
+		ResponseError(w, err, "Error parsing user id", http.StatusBadRequest)
+		return
+	}
+	if followeeID == followerID {
+		ResponseError(w, nil, "Users cannot follow themselves", http.StatusBadRequest)
+		return
+	}
+
+	followee, err := cfg.db.GetUserById(context.Background(), followeeID)
+	if err != nil {
+		ResponseError(w, err, "User not found", http.StatusNotFound)
+		return
+	}
+
+	blocked, err := cfg.db.BlockExistsBetween(context.Background(), database.BlockExistsBetweenParams{
+		UserID:  followerID,
+		OtherID: followeeID,
+	})
+	if err != nil {
+		ResponseError(w, err, "Error following user", http.StatusInternalServerError)
+		return
+	}
+	if blocked {
+		ResponseError(w, nil, "Cannot follow this user", http.StatusForbidden)
+		return
+	}
+
+	if followee.Protected {
+		cfg.requestFollow(w, followerID, followeeID)
+		return
+	}
+
+	tx, err := cfg.dbConn.BeginTx(context.Background(), nil)
+	if err != nil {
+		ResponseError(w, err, "Error following user", http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.db.WithTx(tx)
+
+	created, err := addFollow(context.Background(), qtx, followerID, followee)
+	if err != nil {
+		ResponseError(w, err, "Error following user", http.StatusInternalServerError)
+		return
+	}
+	if !created {
+		ResponseError(w, nil, "Already following user", http.StatusConflict)
+		return
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		ResponseError(w, err, "Error following user", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (cfg *apiConfig) handlerUnfollowUser(w http.ResponseWriter, r *http.Request) {
+
+	followerID, err := cfg.authenticateRequest(r)
+	if err != nil {
+		ResponseError(w, err, "User not authenticated", http.StatusUnauthorized)
+		return
+	}
+
+	followeeID, err := uuid.Parse(r.PathValue("userID"))
+	if err != nil {
+		ResponseError(w, err, "Error parsing user id", http.StatusBadRequest)
+		return
+	}
+
+	tx, err := cfg.dbConn.BeginTx(context.Background(), nil)
+	if err != nil {
+		ResponseError(w, err, "Error unfollowing user", http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.db.WithTx(tx)
+
+	deleted, err := removeFollow(context.Background(), qtx, followerID, followeeID)
+	if err != nil {
+		ResponseError(w, err, "Error unfollowing user", http.StatusInternalServerError)
+		return
+	}
+
+	// Unfollowing a protected account also withdraws a pending request
+	withdrawn, err := qtx.DeleteFollowRequest(context.Background(), database.DeleteFollowRequestParams{
+		RequesterID: followerID,
+		TargetID:    followeeID,
+	})
+	if err != nil {
+		ResponseError(w, err, "Error unfollowing user", http.StatusInternalServerError)
+		return
+	}
+	if !deleted && withdrawn == 0 {
+		ResponseError(w, nil, "Not following user", http.StatusNotFound)
+		return
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		ResponseError(w, err, "Error unfollowing user", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// requestFollow asks a protected account to approve a follow. The follow
+// itself is only created once the account approves it.
+func (cfg *apiConfig) requestFollow(w http.ResponseWriter, followerID, followeeID uuid.UUID) {
+	following, err := cfg.db.IsFollowing(context.Background(), database.IsFollowingParams{
+		FollowerID: followerID,
+		FolloweeID: followeeID,
+	})
+	if err != nil {
+		ResponseError(w, err, "Error following user", http.StatusInternalServerError)
+		return
+	}
+	if following {
+		ResponseError(w, nil, "Already following user", http.StatusConflict)
+		return
+	}
+
+	created, err := cfg.db.CreateFollowRequest(context.Background(), database.CreateFollowRequestParams{
+		RequesterID: followerID,
+		TargetID:    followeeID,
+		CreatedAt:   time.Now(),
+	})
+	if err != nil {
+		ResponseError(w, err, "Error following user", http.StatusInternalServerError)
+		return
+	}
+	if created == 0 {
+		ResponseError(w, nil, "Follow already requested", http.StatusConflict)
+		return
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// addFollow creates a follow along with its counts. It also fills the
+// follower's inbox with the account's recent chirps that were fanned out, so
+// the follow shows up in the timeline straight away; the rest are merged in
+// when the timeline is read. It reports whether the follow is new.
+func addFollow(ctx context.Context, db *database.Queries, followerID uuid.UUID, followee database.User) (bool, error) {
+	created, err := db.CreateFollow(ctx, database.CreateFollowParams{
+		FollowerID: followerID,
+		FolloweeID: followee.ID,
+		CreatedAt:  time.Now(),
+	})
+	if err != nil || created == 0 {
+		return false, err
+	}
+	err = db.IncrementFollowCounts(ctx, database.IncrementFollowCountsParams{
+		FolloweeID: followee.ID,
+		FollowerID: followerID,
+	})
+	if err != nil {
+		return false, err
+	}
+	err = db.BackfillTimeline(ctx, database.BackfillTimelineParams{
+		UserID:   followerID,
+		AuthorID: followee.ID,
+		RowLimit: timeline.BackfillSize,
+	})
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// removeFollow deletes a follow along with the counts and timeline entries
+// that come with it. It reports whether there was a follow to delete.
+func removeFollow(ctx context.Context, db *database.Queries, followerID, followeeID uuid.UUID) (bool, error) {
+	deleted, err := db.DeleteFollow(ctx, database.DeleteFollowParams{
+		FollowerID: followerID,
+		FolloweeID: followeeID,
+	})
+	if err != nil || deleted == 0 {
+		return false, err
+	}
+	err = db.DecrementFollowCounts(ctx, database.DecrementFollowCountsParams{
+		FolloweeID: followeeID,
+		FollowerID: followerID,
+	})
+	if err != nil {
+		return false, err
+	}
+	err = db.DeleteTimelineEntriesByAuthor(ctx, database.DeleteTimelineEntriesByAuthorParams{
+		UserID:   followerID,
+		AuthorID: followeeID,
+	})
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (cfg *apiConfig) handlerGetFollowers(w http.ResponseWriter, r *http.Request) {
+	cfg.respondFollowList(w, r, true)
+}
+
+func (cfg *apiConfig) handlerGetFollowing(w http.ResponseWriter, r *http.Request) {
+	cfg.respondFollowList(w, r, false)
+}
+
+// respondFollowList writes a page of the follows pointing at the user in the
+// path when followers is set, or of the follows made by that user otherwise.
+func (cfg *apiConfig) respondFollowList(w http.ResponseWriter, r *http.Request, followers bool) {
+
+	userID, err := uuid.Parse(r.PathValue("userID"))
+	if err != nil {
+		ResponseError(w, err, "Error parsing user id", http.StatusBadRequest)
+		return
+	}
+
+	page, err := pagination.ParseRequest(r.URL.Query())
+	if err != nil {
+		ResponseError(w, err, "Invalid pagination parameters", http.StatusBadRequest)
+		return
+	}
+
+	_, err = cfg.db.GetUserById(context.Background(), userID)
+	if err != nil {
+		ResponseError(w, err, "User not found", http.StatusNotFound)
+		return
+	}
+
+	// Fetch one extra row to find out whether there is a next page
+	var follows []database.Follow
+	if followers {
+		follows, err = cfg.db.ListFollowers(context.Background(), database.ListFollowersParams{
+			UserID:          userID,
+			HasCursor:       page.HasCursor,
+			CursorCreatedAt: page.Cursor.CreatedAt,
+			CursorID:        page.Cursor.ID,
+			RowLimit:        int32(page.Limit + 1),
+		})
+	} else {
+		follows, err = cfg.db.ListFollowing(context.Background(), database.ListFollowingParams{
+			UserID:          userID,
+			HasCursor:       page.HasCursor,
+			CursorCreatedAt: page.Cursor.CreatedAt,
+			CursorID:        page.Cursor.ID,
+			RowLimit:        int32(page.Limit + 1),
+		})
+	}
+	if err != nil {
+		ResponseError(w, err, "Error retrieving follows", http.StatusInternalServerError)
+		return
+	}
+	follows, next := pagination.Trim(follows, page.Limit, func(follow database.Follow) pagination.Cursor {
+		if followers {
+			return pagination.Cursor{CreatedAt: follow.CreatedAt, ID: follow.FollowerID}
+		}
+		return pagination.Cursor{CreatedAt: follow.CreatedAt, ID: follow.FolloweeID}
+	})
+
+	out := []Follow{}
+	for _, item := range follows {
+		out = append(out, dbFollowToFollow(item))
+	}
+
+	SetNextCursor(w, r, next)
+	data, err := json.Marshal(out)
+	SetJSONResponse(w, http.StatusOK, data, err)
+}
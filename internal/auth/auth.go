@@ -12,8 +12,7 @@ func GetBearerToken(headers http.Header) (string, error) {
 		return "", fmt.Errorf("missing authorization header")
 	}
 	slice := strings.Split(auth_header, " ")
-	fmt.Println(len(slice), slice[0])
-	if len(slice) != 2 && slice[0] != "Bearer" {
+	if len(slice) != 2 || slice[0] != "Bearer" {
 		return "", fmt.Errorf("invalid authorization header")
 	}
 	return slice[1], nil
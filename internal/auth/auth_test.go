@@ -12,9 +12,9 @@ import (
 func TestValidBearerToken(t *testing.T) {
 
 	userID := uuid.New()
-	tokenSecret := "ThisIsATestSecret"
+	keys := testKeySet(t)
 	expiry, _ := time.ParseDuration("2s")
-	token, err := MakeJWT(userID, tokenSecret, expiry)
+	token, err := MakeJWT(userID, keys, expiry)
 	if err != nil {
 		t.Fatal("Failed to create token")
 	}
@@ -34,9 +34,9 @@ func TestValidBearerToken(t *testing.T) {
 func TestInvalidBearerToken(t *testing.T) {
 
 	userID := uuid.New()
-	tokenSecret := "ThisIsATestSecret"
+	keys := testKeySet(t)
 	expiry, _ := time.ParseDuration("2s")
-	token, err := MakeJWT(userID, tokenSecret, expiry)
+	token, err := MakeJWT(userID, keys, expiry)
 	if err != nil {
 		t.Fatal("Failed to create token")
 	}
@@ -48,7 +48,7 @@ func TestInvalidBearerToken(t *testing.T) {
 	if err != nil {
 		t.Fatalf("Failed to get bearer token: %v", err)
 	}
-	_, err = ValidateJWT(result, tokenSecret)
+	_, err = ValidateJWT(result, keys)
 	if err == nil {
 		t.Fatal("Validation succeeded when it should have failed")
 	}
@@ -58,9 +58,9 @@ func TestInvalidBearerToken(t *testing.T) {
 func TestInvalidBearerToken2(t *testing.T) {
 
 	userID := uuid.New()
-	tokenSecret := "ThisIsATestSecret"
+	keys := testKeySet(t)
 	expiry, _ := time.ParseDuration("2s")
-	token, err := MakeJWT(userID, tokenSecret, expiry)
+	token, err := MakeJWT(userID, keys, expiry)
 	if err != nil {
 		t.Fatal("Failed to create token")
 	}
@@ -2,77 +2,159 @@ package auth
 
 import (
 	"crypto/rand"
+	"crypto/sha256"
 	"encoding/hex"
 	"fmt"
+	"slices"
+	"strings"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/google/uuid"
 )
 
-func MakeAccessToken(userID uuid.UUID, tokenSecret string) (string, error) {
+// Claims are what a valid access token says about its bearer.
+type Claims struct {
+	UserID uuid.UUID
+	// SessionID is the family of refresh tokens the access token was issued
+	// from. Tokens made by MakeJWT don't have one.
+	SessionID uuid.NullUUID
+	// Scopes are what the token may be used for.
+	Scopes    []string
+	ExpiresAt time.Time
+	// PersonalAccessToken is set when the bearer token is a personal access
+	// token rather than one issued at login.
+	PersonalAccessToken bool
+}
+
+// HasScope reports whether the claims grant scope.
+func (c Claims) HasScope(scope string) bool {
+	return slices.Contains(c.Scopes, scope)
+}
+
+// tokenClaims are the claims as they are encoded in a token. Scopes are
+// space separated in a single claim, as in OAuth.
+type tokenClaims struct {
+	jwt.RegisteredClaims
+	SessionID string `json:"sid,omitempty"`
+	Scope     string `json:"scope,omitempty"`
+}
+
+// MakeAccessToken makes a short-lived token for userID, issued from the
+// session sessionID and granting scopes.
+func MakeAccessToken(userID, sessionID uuid.UUID, scopes []string, keys *KeySet) (string, error) {
 	accessExpiry := time.Hour
-	return MakeJWT(userID, tokenSecret, accessExpiry)
+	return makeJWT(userID, sessionID.String(), scopes, keys, accessExpiry)
 }
 
-func MakeJWT(userID uuid.UUID, tokenSecret string, expiresIn time.Duration) (string, error) {
+func MakeJWT(userID uuid.UUID, keys *KeySet, expiresIn time.Duration) (string, error) {
+	return makeJWT(userID, "", nil, keys, expiresIn)
+}
+
+// makeJWT signs a token with the signing key of keys, naming the key in the
+// kid header so it can be verified after the next key takes over.
+func makeJWT(userID uuid.UUID, sessionID string, scopes []string, keys *KeySet, expiresIn time.Duration) (string, error) {
 	now := time.Now().UTC()
 	token := jwt.NewWithClaims(
-		jwt.SigningMethodHS256,
-		jwt.RegisteredClaims{
-			Issuer:    "chirpy",
-			IssuedAt:  jwt.NewNumericDate(now),
-			ExpiresAt: jwt.NewNumericDate(now.Add(expiresIn)),
-			Subject:   userID.String(),
+		jwt.SigningMethodEdDSA,
+		tokenClaims{
+			RegisteredClaims: jwt.RegisteredClaims{
+				Issuer:    "chirpy",
+				IssuedAt:  jwt.NewNumericDate(now),
+				ExpiresAt: jwt.NewNumericDate(now.Add(expiresIn)),
+				Subject:   userID.String(),
+			},
+			SessionID: sessionID,
+			Scope:     strings.Join(scopes, " "),
 		},
 	)
-	jwt, err := token.SignedString([]byte(tokenSecret))
+	token.Header["kid"] = keys.signing.ID
+	jwt, err := token.SignedString(keys.signing.private)
 	if err != nil {
 		return "", err
 	}
 	return jwt, nil
 }
 
-func ValidateJWT(tokenString, tokenSecret string) (uuid.UUID, error) {
-	// Parse the jwt
+func ValidateJWT(tokenString string, keys *KeySet) (Claims, error) {
+	// Parse the jwt, verifying it with the key it names
+	claims := tokenClaims{}
 	token, err := jwt.ParseWithClaims(
 		tokenString,
-		&jwt.RegisteredClaims{},
+		&claims,
 		func(t *jwt.Token) (interface{}, error) {
-			return []byte(tokenSecret), nil
+			kid, _ := t.Header["kid"].(string)
+			return keys.lookup(kid)
 		},
+		jwt.WithValidMethods([]string{jwt.SigningMethodEdDSA.Alg()}),
 	)
 	if err != nil {
-		return uuid.UUID{}, fmt.Errorf("failed to parse token: %v", err)
+		return Claims{}, fmt.Errorf("failed to parse token: %v", err)
 	}
 
 	// Checks issuer
 	issuer, err := token.Claims.GetIssuer()
 	if err != nil {
-		return uuid.UUID{}, fmt.Errorf("failed to retrieve issuer from claim: %v", err)
+		return Claims{}, fmt.Errorf("failed to retrieve issuer from claim: %v", err)
 	} else if issuer != "chirpy" {
-		return uuid.UUID{}, fmt.Errorf("invalid issuer")
+		return Claims{}, fmt.Errorf("invalid issuer")
 	}
 
 	// Checks expiry
 	expiry, err := token.Claims.GetExpirationTime()
 	if err != nil {
-		return uuid.UUID{}, fmt.Errorf("failed to retrieve expiration time from claim: %v", err)
+		return Claims{}, fmt.Errorf("failed to retrieve expiration time from claim: %v", err)
 	} else if expiry.Before(time.Now().UTC()) {
-		return uuid.UUID{}, fmt.Errorf("token expired")
+		return Claims{}, fmt.Errorf("token expired")
 	}
 
 	// Gets user id
 	user_id, err := token.Claims.GetSubject()
 	if err != nil {
-		return uuid.UUID{}, fmt.Errorf("failed to retrieve subject from claim: %v", err)
+		return Claims{}, fmt.Errorf("failed to retrieve subject from claim: %v", err)
 	}
 
 	id, err := uuid.Parse(user_id)
 	if err != nil {
-		return uuid.UUID{}, fmt.Errorf("failed to parse subject into uuid: %v", err)
+		return Claims{}, fmt.Errorf("failed to parse subject into uuid: %v", err)
 	}
-	return id, nil
+
+	// Gets session id
+	var sessionID uuid.NullUUID
+	if claims.SessionID != "" {
+		sessionID.UUID, err = uuid.Parse(claims.SessionID)
+		if err != nil {
+			return Claims{}, fmt.Errorf("failed to parse session id into uuid: %v", err)
+		}
+		sessionID.Valid = true
+	}
+
+	return Claims{
+		UserID:    id,
+		SessionID: sessionID,
+		Scopes:    strings.Fields(claims.Scope),
+		ExpiresAt: expiry.Time,
+	}, nil
+}
+
+// PersonalAccessTokenPrefix starts every personal access token, telling them
+// apart from JWTs and making them easy to spot if they leak.
+const PersonalAccessTokenPrefix = "chirpy_pat_"
+
+// MakePersonalAccessToken makes a random token for a user to authenticate
+// scripts with instead of their password.
+func MakePersonalAccessToken() (string, error) {
+	token, err := MakeRefreshToken()
+	if err != nil {
+		return "", err
+	}
+	return PersonalAccessTokenPrefix + token, nil
+}
+
+// IsPersonalAccessToken reports whether a bearer token is a personal access
+// token rather than a JWT.
+func IsPersonalAccessToken(token string) bool {
+	return strings.HasPrefix(token, PersonalAccessTokenPrefix)
 }
 
 func MakeRefreshToken() (string, error) {
@@ -84,3 +166,12 @@ func MakeRefreshToken() (string, error) {
 	token := hex.EncodeToString(data[:])
 	return token, nil
 }
+
+// HashToken returns the hex SHA-256 digest refresh tokens and personal
+// access tokens are stored and looked up by, so the database never holds a
+// usable token. Tokens carry 256 random bits, which makes a fast unsalted
+// hash enough.
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
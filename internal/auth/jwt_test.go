@@ -10,30 +10,35 @@ import (
 
 func TestValidJWT(t *testing.T) {
 	userID := uuid.New()
-	tokenSecret := "ThisIsATestSecret"
+	keys := testKeySet(t)
 	expiry, _ := time.ParseDuration("2s")
-	token, err := MakeJWT(userID, tokenSecret, expiry)
+	token, err := MakeJWT(userID, keys, expiry)
 	if err != nil {
 		t.Fatal("Failed to create token")
 	}
-	result, err := ValidateJWT(token, tokenSecret)
+	result, err := ValidateJWT(token, keys)
 	if err != nil {
 		t.Fatalf("Failed to validate token: %v", err)
 	}
-	if result != userID {
-		t.Fatalf("Failed: Have '%s' want '%s'", result.String(), userID.String())
+	if result.UserID != userID {
+		t.Fatalf("Failed: Have '%s' want '%s'", result.UserID.String(), userID.String())
 	}
 }
 
 func TestInvalidJWTsecret(t *testing.T) {
 	userID := uuid.New()
-	tokenSecret := "ThisIsATestSecret"
+	keys := testKeySet(t)
 	expiry, _ := time.ParseDuration("2s")
-	token, err := MakeJWT(userID, tokenSecret, expiry)
+	token, err := MakeJWT(userID, keys, expiry)
 	if err != nil {
 		t.Fatal("Failed to create token")
 	}
-	_, err = ValidateJWT(token, "tokenSecret")
+	// A different key claiming the same key id
+	other := testKeySet(t)
+	forged := &KeySet{byID: map[string]Key{
+		keys.signing.ID: {ID: keys.signing.ID, public: other.signing.public},
+	}}
+	_, err = ValidateJWT(token, forged)
 	if err == nil {
 		t.Fatalf("Token valid but should be invalid")
 	}
@@ -45,21 +50,21 @@ func TestInvalidJWTsecret(t *testing.T) {
 
 func TestExpiredJWT(t *testing.T) {
 	userID := uuid.New()
-	tokenSecret := "ThisIsATestSecret"
+	keys := testKeySet(t)
 	expiry, _ := time.ParseDuration("1s")
-	token, err := MakeJWT(userID, tokenSecret, expiry)
+	token, err := MakeJWT(userID, keys, expiry)
 	if err != nil {
 		t.Fatal("Failed to create token")
 	}
-	result, err := ValidateJWT(token, tokenSecret)
+	result, err := ValidateJWT(token, keys)
 	if err != nil {
 		t.Fatalf("Failed to validate token: %v", err)
 	}
-	if result != userID {
-		t.Fatalf("Failed: Have '%s' want '%s'", result.String(), userID.String())
+	if result.UserID != userID {
+		t.Fatalf("Failed: Have '%s' want '%s'", result.UserID.String(), userID.String())
 	}
 	time.Sleep(expiry)
-	_, err = ValidateJWT(token, tokenSecret)
+	_, err = ValidateJWT(token, keys)
 	if err == nil {
 		t.Fatalf("Token valid but should be invalid")
 	}
@@ -68,3 +73,99 @@ func TestExpiredJWT(t *testing.T) {
 		t.Fatalf("Should contain '%s', got '%v'", expected_error, err)
 	}
 }
+
+func TestHashToken(t *testing.T) {
+	token, err := MakeRefreshToken()
+	if err != nil {
+		t.Fatal("Failed to create refresh token")
+	}
+	hash := HashToken(token)
+	if hash == token {
+		t.Fatalf("Failed: hash equals token '%s'", token)
+	}
+	if len(hash) != 64 {
+		t.Fatalf("Failed: Have length %d want 64", len(hash))
+	}
+	if HashToken(token) != hash {
+		t.Fatalf("Failed: hashing the same token twice differs")
+	}
+
+	// SHA-256 of "abc"
+	have := HashToken("abc")
+	want := "ba7816bf8f01cfea414140de5dae2223b00361a396177a9cb410ff61f20015ad"
+	if have != want {
+		t.Fatalf("Failed: Have '%s' want '%s'", have, want)
+	}
+}
+
+func TestAccessTokenClaims(t *testing.T) {
+	userID := uuid.New()
+	sessionID := uuid.New()
+	keys := testKeySet(t)
+
+	token, err := MakeAccessToken(userID, sessionID, UserScopes(false), keys)
+	if err != nil {
+		t.Fatal("Failed to create token")
+	}
+	claims, err := ValidateJWT(token, keys)
+	if err != nil {
+		t.Fatalf("Failed to validate token: %v", err)
+	}
+	if claims.UserID != userID {
+		t.Fatalf("Failed: Have '%s' want '%s'", claims.UserID.String(), userID.String())
+	}
+	if !claims.SessionID.Valid || claims.SessionID.UUID != sessionID {
+		t.Fatalf("Failed: Have '%s' want '%s'", claims.SessionID.UUID.String(), sessionID.String())
+	}
+	tests := []struct {
+		scope string
+		want  bool
+	}{
+		{ScopeChirpsWrite, true},
+		{ScopeUsersWrite, true},
+		{ScopeAdmin, false},
+		{"", false},
+	}
+	for _, test := range tests {
+		if have := claims.HasScope(test.scope); have != test.want {
+			t.Errorf("HasScope(%q): have %v want %v", test.scope, have, test.want)
+		}
+	}
+
+	// Tokens made without a session or scopes validate without them
+	token, err = MakeJWT(userID, keys, time.Minute)
+	if err != nil {
+		t.Fatal("Failed to create token")
+	}
+	claims, err = ValidateJWT(token, keys)
+	if err != nil {
+		t.Fatalf("Failed to validate token: %v", err)
+	}
+	if claims.SessionID.Valid {
+		t.Fatalf("Failed: Have session '%s' want none", claims.SessionID.UUID.String())
+	}
+	if len(claims.Scopes) != 0 {
+		t.Fatalf("Failed: Have scopes %v want none", claims.Scopes)
+	}
+}
+
+func TestPersonalAccessToken(t *testing.T) {
+	token, err := MakePersonalAccessToken()
+	if err != nil {
+		t.Fatal("Failed to create personal access token")
+	}
+	if !IsPersonalAccessToken(token) {
+		t.Fatalf("Failed: '%s' not recognised as a personal access token", token)
+	}
+	if len(token) != len(PersonalAccessTokenPrefix)+64 {
+		t.Fatalf("Failed: Have length %d want %d", len(token), len(PersonalAccessTokenPrefix)+64)
+	}
+
+	jwt, err := MakeJWT(uuid.New(), testKeySet(t), time.Minute)
+	if err != nil {
+		t.Fatal("Failed to create token")
+	}
+	if IsPersonalAccessToken(jwt) {
+		t.Fatalf("Failed: JWT '%s' recognised as a personal access token", jwt)
+	}
+}
@@ -0,0 +1,156 @@
+package auth
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+)
+
+// Key is an Ed25519 key access tokens are signed or verified with. Keys
+// loaded from a public key can only verify.
+type Key struct {
+	// ID is the key's RFC 7638 thumbprint, sent as the kid header of the
+	// tokens it signs.
+	ID      string
+	public  ed25519.PublicKey
+	private ed25519.PrivateKey
+}
+
+func newKey(public ed25519.PublicKey, private ed25519.PrivateKey) Key {
+	return Key{
+		ID:      thumbprint(public),
+		public:  public,
+		private: private,
+	}
+}
+
+// GenerateKey makes a new signing key.
+func GenerateKey() (Key, error) {
+	public, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return Key{}, err
+	}
+	return newKey(public, private), nil
+}
+
+// ParseKeyPEM reads an Ed25519 private key in PKCS #8 form, as written by
+// "openssl genpkey -algorithm ed25519", or a public key in PKIX form, as
+// written by "openssl pkey -pubout".
+func ParseKeyPEM(data []byte) (Key, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return Key{}, errors.New("no PEM data found")
+	}
+	switch block.Type {
+	case "PRIVATE KEY":
+		parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+		if err != nil {
+			return Key{}, err
+		}
+		private, ok := parsed.(ed25519.PrivateKey)
+		if !ok {
+			return Key{}, fmt.Errorf("unsupported private key type %T", parsed)
+		}
+		return newKey(private.Public().(ed25519.PublicKey), private), nil
+	case "PUBLIC KEY":
+		parsed, err := x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			return Key{}, err
+		}
+		public, ok := parsed.(ed25519.PublicKey)
+		if !ok {
+			return Key{}, fmt.Errorf("unsupported public key type %T", parsed)
+		}
+		return newKey(public, nil), nil
+	default:
+		return Key{}, fmt.Errorf("unsupported PEM block %q", block.Type)
+	}
+}
+
+// thumbprint computes the RFC 7638 thumbprint of an Ed25519 public key: the
+// SHA-256 of its JWK's required members, in lexical order and without
+// whitespace.
+func thumbprint(public ed25519.PublicKey) string {
+	members := fmt.Sprintf(`{"crv":"Ed25519","kty":"OKP","x":"%s"}`, base64.RawURLEncoding.EncodeToString(public))
+	sum := sha256.Sum256([]byte(members))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// KeySet holds the keys that are currently trusted. Tokens are signed with
+// the first key, and any key in the set verifies them, which lets keys be
+// rotated: publish a new key alongside the old one, start signing with it,
+// and drop the old key once the tokens it signed have expired.
+type KeySet struct {
+	signing Key
+	keys    []Key
+	byID    map[string]Key
+}
+
+// NewKeySet makes a key set that signs with the first of keys, which must be
+// a private key.
+func NewKeySet(keys ...Key) (*KeySet, error) {
+	if len(keys) == 0 {
+		return nil, errors.New("no keys given")
+	}
+	if keys[0].private == nil {
+		return nil, errors.New("the first key must be a private key to sign with")
+	}
+	ks := &KeySet{
+		signing: keys[0],
+		byID:    map[string]Key{},
+	}
+	for _, key := range keys {
+		if _, ok := ks.byID[key.ID]; ok {
+			continue
+		}
+		ks.keys = append(ks.keys, key)
+		ks.byID[key.ID] = key
+	}
+	return ks, nil
+}
+
+// lookup finds the public key a token names in its kid header.
+func (ks *KeySet) lookup(kid string) (ed25519.PublicKey, error) {
+	key, ok := ks.byID[kid]
+	if !ok {
+		return nil, fmt.Errorf("unknown key id %q", kid)
+	}
+	return key.public, nil
+}
+
+// JWK is a public key in JSON Web Key form.
+type JWK struct {
+	KeyType   string `json:"kty"`
+	Curve     string `json:"crv"`
+	X         string `json:"x"`
+	KeyID     string `json:"kid"`
+	Algorithm string `json:"alg"`
+	Use       string `json:"use"`
+}
+
+// JWKS is a JSON Web Key Set.
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// JWKS returns the public half of every key in the set, for services that
+// verify access tokens.
+func (ks *KeySet) JWKS() JWKS {
+	out := JWKS{Keys: []JWK{}}
+	for _, key := range ks.keys {
+		out.Keys = append(out.Keys, JWK{
+			KeyType:   "OKP",
+			Curve:     "Ed25519",
+			X:         base64.RawURLEncoding.EncodeToString(key.public),
+			KeyID:     key.ID,
+			Algorithm: "EdDSA",
+			Use:       "sig",
+		})
+	}
+	return out
+}
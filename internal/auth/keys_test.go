@@ -0,0 +1,149 @@
+package auth
+
+import (
+	"crypto/ed25519"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func testKeySet(t *testing.T) *KeySet {
+	t.Helper()
+	key, err := GenerateKey()
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+	keys, err := NewKeySet(key)
+	if err != nil {
+		t.Fatalf("Failed to create key set: %v", err)
+	}
+	return keys
+}
+
+func TestParseKeyPEM(t *testing.T) {
+	public, private, _ := ed25519.GenerateKey(nil)
+	privateDER, err := x509.MarshalPKCS8PrivateKey(private)
+	if err != nil {
+		t.Fatal(err)
+	}
+	publicDER, err := x509.MarshalPKIXPublicKey(public)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	privateKey, err := ParseKeyPEM(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: privateDER}))
+	if err != nil {
+		t.Fatalf("Failed to parse private key: %v", err)
+	}
+	publicKey, err := ParseKeyPEM(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: publicDER}))
+	if err != nil {
+		t.Fatalf("Failed to parse public key: %v", err)
+	}
+	if privateKey.ID != publicKey.ID {
+		t.Errorf("key ids: have '%s' and '%s' want equal", privateKey.ID, publicKey.ID)
+	}
+	if privateKey.private == nil || publicKey.private != nil {
+		t.Errorf("only the private key should be able to sign")
+	}
+
+	_, err = ParseKeyPEM([]byte("not a key"))
+	if err == nil {
+		t.Errorf("parsing non-PEM data should fail")
+	}
+}
+
+func TestThumbprint(t *testing.T) {
+	// RFC 8037 appendix A.3
+	x, _ := base64.RawURLEncoding.DecodeString("11qYAYKxCrfVS_7TyWQHOg7hcvPapiMlrwIaaPcHURo")
+	have := thumbprint(ed25519.PublicKey(x))
+	want := "kPrK_qmxVWaYVA9wwBF6Iuo3vVzz7TxHCTwXBygrS4k"
+	if have != want {
+		t.Errorf("thumbprint: have '%s' want '%s'", have, want)
+	}
+}
+
+func TestNewKeySetNeedsSigningKey(t *testing.T) {
+	key, _ := GenerateKey()
+	_, err := NewKeySet(Key{ID: key.ID, public: key.public})
+	if err == nil {
+		t.Errorf("a key set without a private first key should be rejected")
+	}
+	_, err = NewKeySet()
+	if err == nil {
+		t.Errorf("an empty key set should be rejected")
+	}
+}
+
+func TestKeyRotation(t *testing.T) {
+	userID := uuid.New()
+	oldKey, _ := GenerateKey()
+	newKey, _ := GenerateKey()
+
+	before, _ := NewKeySet(oldKey)
+	during, _ := NewKeySet(newKey, oldKey)
+	after, _ := NewKeySet(newKey)
+
+	oldToken, err := MakeJWT(userID, before, time.Minute)
+	if err != nil {
+		t.Fatal("Failed to create token")
+	}
+	newToken, err := MakeJWT(userID, during, time.Minute)
+	if err != nil {
+		t.Fatal("Failed to create token")
+	}
+
+	tests := []struct {
+		name  string
+		token string
+		keys  *KeySet
+		valid bool
+	}{
+		{"old token, old key", oldToken, before, true},
+		{"old token, both keys", oldToken, during, true},
+		{"new token, both keys", newToken, during, true},
+		{"new token, new key", newToken, after, true},
+		{"old token, old key dropped", oldToken, after, false},
+		{"new token, before new key", newToken, before, false},
+	}
+	for _, test := range tests {
+		result, err := ValidateJWT(test.token, test.keys)
+		if test.valid && (err != nil || result.UserID != userID) {
+			t.Errorf("%s: have '%v' want valid", test.name, err)
+		}
+		if !test.valid && err == nil {
+			t.Errorf("%s: have valid want error", test.name)
+		}
+	}
+}
+
+func TestJWKS(t *testing.T) {
+	oldKey, _ := GenerateKey()
+	newKey, _ := GenerateKey()
+	keys, _ := NewKeySet(newKey, oldKey, newKey)
+
+	jwks := keys.JWKS()
+	if len(jwks.Keys) != 2 {
+		t.Fatalf("keys: have %d want 2", len(jwks.Keys))
+	}
+	for i, key := range []Key{newKey, oldKey} {
+		jwk := jwks.Keys[i]
+		if jwk.KeyID != key.ID || jwk.KeyType != "OKP" || jwk.Curve != "Ed25519" || jwk.Algorithm != "EdDSA" {
+			t.Errorf("key %d: have '%+v'", i, jwk)
+		}
+		if jwk.X != base64.RawURLEncoding.EncodeToString(key.public) {
+			t.Errorf("key %d: have x '%s'", i, jwk.X)
+		}
+	}
+
+	// Tokens name the signing key
+	token, _ := MakeJWT(uuid.New(), keys, time.Minute)
+	header, _ := base64.RawURLEncoding.DecodeString(strings.Split(token, ".")[0])
+	if !strings.Contains(string(header), `"kid":"`+newKey.ID+`"`) {
+		t.Errorf("header: have '%s' want kid '%s'", header, newKey.ID)
+	}
+}
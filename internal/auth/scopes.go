@@ -0,0 +1,79 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+)
+
+// Scopes limit what an access token can be used for. Reading needs no scope.
+const (
+	// ScopeChirpsWrite allows posting, editing and deleting chirps, and
+	// rechirping, liking and bookmarking them.
+	ScopeChirpsWrite = "chirps:write"
+	// ScopeUsersWrite allows changing the account: its login, profile,
+	// privacy, relationships with other users, lists and sessions.
+	ScopeUsersWrite = "users:write"
+	// ScopeAdmin allows using the admin endpoints. It is only granted to
+	// administrators.
+	ScopeAdmin = "admin"
+)
+
+// UserScopes are the scopes granted when a user logs in.
+func UserScopes(isAdmin bool) []string {
+	scopes := []string{ScopeChirpsWrite, ScopeUsersWrite}
+	if isAdmin {
+		scopes = append(scopes, ScopeAdmin)
+	}
+	return scopes
+}
+
+// CheckScopes checks that every one of scopes is known and granted.
+func CheckScopes(scopes, granted []string) error {
+	for _, scope := range scopes {
+		switch scope {
+		case ScopeChirpsWrite, ScopeUsersWrite, ScopeAdmin:
+		default:
+			return fmt.Errorf("unknown scope %q", scope)
+		}
+		if !slices.Contains(granted, scope) {
+			return fmt.Errorf("scope %q can't be granted", scope)
+		}
+	}
+	return nil
+}
+
+// ErrTokenFromToken is returned when a personal access token is used to
+// create another.
+var ErrTokenFromToken = errors.New("personal access tokens can't create other tokens")
+
+// CheckNewToken checks that the bearer of c may create a personal access
+// token granting scopes and expiring at expiresAt, or never if it is nil.
+// Only tokens issued at login, where the user proved they know their
+// password, can create them: a personal access token could otherwise outlive
+// itself through the tokens it creates, even after being revoked.
+func (c Claims) CheckNewToken(scopes []string, expiresAt *time.Time, now time.Time) error {
+	if c.PersonalAccessToken {
+		return ErrTokenFromToken
+	}
+	if expiresAt != nil && !expiresAt.After(now) {
+		return errors.New("expiry must be in the future")
+	}
+	return CheckScopes(scopes, c.Scopes)
+}
+
+// ErrLoginFromToken is returned when a personal access token is used to
+// change the email or password of its account.
+var ErrLoginFromToken = errors.New("personal access tokens can't change the login")
+
+// CheckChangeLogin checks that the bearer of c may change the email and
+// password of the account. Like creating tokens, this needs a token issued
+// at login: whoever held a personal access token could otherwise take the
+// account over, log in and create tokens of their own.
+func (c Claims) CheckChangeLogin() error {
+	if c.PersonalAccessToken {
+		return ErrLoginFromToken
+	}
+	return nil
+}
@@ -0,0 +1,80 @@
+package auth
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestCheckScopes(t *testing.T) {
+	tests := []struct {
+		name    string
+		scopes  []string
+		granted []string
+		valid   bool
+	}{
+		{"none", nil, UserScopes(false), true},
+		{"subset", []string{ScopeChirpsWrite}, UserScopes(false), true},
+		{"all", UserScopes(true), UserScopes(true), true},
+		{"unknown", []string{"chirps:delete"}, UserScopes(true), false},
+		{"admin for user", []string{ScopeAdmin}, UserScopes(false), false},
+		{"not granted", []string{ScopeUsersWrite}, []string{ScopeChirpsWrite}, false},
+	}
+	for _, test := range tests {
+		err := CheckScopes(test.scopes, test.granted)
+		if test.valid && err != nil {
+			t.Errorf("%s: have '%v' want valid", test.name, err)
+		}
+		if !test.valid && err == nil {
+			t.Errorf("%s: have valid want error", test.name)
+		}
+	}
+}
+
+func TestCheckNewToken(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	past := now.Add(-time.Minute)
+	future := now.Add(time.Hour)
+	login := Claims{Scopes: UserScopes(false), ExpiresAt: now.Add(time.Hour)}
+	pat := Claims{Scopes: UserScopes(false), ExpiresAt: future, PersonalAccessToken: true}
+	patForever := Claims{Scopes: UserScopes(false), PersonalAccessToken: true}
+
+	tests := []struct {
+		name      string
+		claims    Claims
+		expiresAt *time.Time
+		scopes    []string
+		valid     bool
+	}{
+		{"login, no expiry", login, nil, UserScopes(false), true},
+		{"login, expiring", login, &future, []string{ScopeChirpsWrite}, true},
+		{"login, expired", login, &past, nil, false},
+		{"login, ungranted scope", login, nil, []string{ScopeAdmin}, false},
+		{"expiring token, no expiry", pat, nil, nil, false},
+		{"expiring token, within its expiry", pat, &future, nil, false},
+		{"token without expiry", patForever, &future, nil, false},
+	}
+	for _, test := range tests {
+		err := test.claims.CheckNewToken(test.scopes, test.expiresAt, now)
+		if test.valid && err != nil {
+			t.Errorf("%s: have '%v' want valid", test.name, err)
+		}
+		if !test.valid && err == nil {
+			t.Errorf("%s: have valid want error", test.name)
+		}
+		if test.claims.PersonalAccessToken && !errors.Is(err, ErrTokenFromToken) {
+			t.Errorf("%s: have '%v' want '%v'", test.name, err, ErrTokenFromToken)
+		}
+	}
+}
+
+func TestCheckChangeLogin(t *testing.T) {
+	login := Claims{Scopes: UserScopes(false)}
+	if err := login.CheckChangeLogin(); err != nil {
+		t.Errorf("login: have '%v' want valid", err)
+	}
+	pat := Claims{Scopes: UserScopes(false), PersonalAccessToken: true}
+	if err := pat.CheckChangeLogin(); !errors.Is(err, ErrLoginFromToken) {
+		t.Errorf("personal access token: have '%v' want '%v'", err, ErrLoginFromToken)
+	}
+}
@@ -10,18 +10,23 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 )
 
 const createChirp = `-- name: CreateChirp :one
-INSERT INTO chirps (id, created_at, updated_at, body, user_id)
+INSERT INTO chirps (id, created_at, updated_at, body, user_id, parent_id, root_id, quote_of, fanned_out)
 VALUES (
     $1,
     $2,
     $3,
     $4,
-    $5
+    $5,
+    $6,
+    $7,
+    $8,
+    $9
 )
-RETURNING id, created_at, updated_at, body, user_id
+RETURNING id, created_at, updated_at, body, user_id, revision_count, parent_id, root_id, quote_of, like_count, search_vector, fanned_out
 `
 
 type CreateChirpParams struct {
@@ -30,6 +35,10 @@ type CreateChirpParams struct {
 	UpdatedAt time.Time
 	Body      string
 	UserID    uuid.UUID
+	ParentID  uuid.NullUUID
+	RootID    uuid.UUID
+	QuoteOf   uuid.NullUUID
+	FannedOut bool
 }
 
 func (q *Queries) CreateChirp(ctx context.Context, arg CreateChirpParams) (Chirp, error) {
@@ -39,6 +48,10 @@ func (q *Queries) CreateChirp(ctx context.Context, arg CreateChirpParams) (Chirp
 		arg.UpdatedAt,
 		arg.Body,
 		arg.UserID,
+		arg.ParentID,
+		arg.RootID,
+		arg.QuoteOf,
+		arg.FannedOut,
 	)
 	var i Chirp
 	err := row.Scan(
@@ -47,10 +60,28 @@ func (q *Queries) CreateChirp(ctx context.Context, arg CreateChirpParams) (Chirp
 		&i.UpdatedAt,
 		&i.Body,
 		&i.UserID,
+		&i.RevisionCount,
+		&i.ParentID,
+		&i.RootID,
+		&i.QuoteOf,
+		&i.LikeCount,
+		&i.SearchVector,
+		&i.FannedOut,
 	)
 	return i, err
 }
 
+const decrementChirpLikeCount = `-- name: DecrementChirpLikeCount :exec
+UPDATE chirps
+SET like_count = like_count - 1
+WHERE id = $1
+`
+
+func (q *Queries) DecrementChirpLikeCount(ctx context.Context, id uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, decrementChirpLikeCount, id)
+	return err
+}
+
 const deleteChirp = `-- name: DeleteChirp :exec
 DELETE FROM chirps
 WHERE id = $1
@@ -61,13 +92,102 @@ func (q *Queries) DeleteChirp(ctx context.Context, id uuid.UUID) error {
 	return err
 }
 
-const getAllChirps = `-- name: GetAllChirps :many
-SELECT id, created_at, updated_at, body, user_id FROM chirps
-ORDER BY created_at ASC
+const getChirpByID = `-- name: GetChirpByID :one
+SELECT id, created_at, updated_at, body, user_id, revision_count, parent_id, root_id, quote_of, like_count, search_vector, fanned_out FROM chirps
+WHERE id = $1
 `
 
-func (q *Queries) GetAllChirps(ctx context.Context) ([]Chirp, error) {
-	rows, err := q.db.QueryContext(ctx, getAllChirps)
+func (q *Queries) GetChirpByID(ctx context.Context, id uuid.UUID) (Chirp, error) {
+	row := q.db.QueryRowContext(ctx, getChirpByID, id)
+	var i Chirp
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Body,
+		&i.UserID,
+		&i.RevisionCount,
+		&i.ParentID,
+		&i.RootID,
+		&i.QuoteOf,
+		&i.LikeCount,
+		&i.SearchVector,
+		&i.FannedOut,
+	)
+	return i, err
+}
+
+const getChirpForUpdate = `-- name: GetChirpForUpdate :one
+SELECT id, created_at, updated_at, body, user_id, revision_count, parent_id, root_id, quote_of, like_count, search_vector, fanned_out FROM chirps
+WHERE id = $1
+FOR UPDATE
+`
+
+func (q *Queries) GetChirpForUpdate(ctx context.Context, id uuid.UUID) (Chirp, error) {
+	row := q.db.QueryRowContext(ctx, getChirpForUpdate, id)
+	var i Chirp
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Body,
+		&i.UserID,
+		&i.RevisionCount,
+		&i.ParentID,
+		&i.RootID,
+		&i.QuoteOf,
+		&i.LikeCount,
+		&i.SearchVector,
+		&i.FannedOut,
+	)
+	return i, err
+}
+
+const getChirpThread = `-- name: GetChirpThread :many
+WITH RECURSIVE thread (id, depth) AS (
+    SELECT starts.id, CASE WHEN starts.id = $1 THEN 0 WHEN starts.parent_id = $1 THEN 1 ELSE 2 END
+    FROM chirps starts
+    WHERE starts.root_id = $1
+    AND (starts.id = $1 OR NOT EXISTS (SELECT 1 FROM chirps parents WHERE parents.id = starts.parent_id))
+    UNION ALL
+    SELECT replies.id, thread.depth + 1
+    FROM thread JOIN chirps replies ON replies.parent_id = thread.id
+    WHERE thread.depth < $2::integer
+)
+SELECT chirps.id, chirps.created_at, chirps.updated_at, chirps.body, chirps.user_id, chirps.revision_count, chirps.parent_id, chirps.root_id, chirps.quote_of, chirps.like_count, chirps.search_vector, chirps.fanned_out FROM chirps
+JOIN thread ON thread.id = chirps.id
+WHERE thread.depth <= $2::integer
+AND (chirps.id = $3 OR (
+    NOT EXISTS (SELECT 1 FROM blocks WHERE blocks.blocker_id = chirps.user_id AND blocks.blocked_id = $4)
+    AND NOT EXISTS (SELECT 1 FROM mutes WHERE mutes.muter_id = $4 AND mutes.muted_id = chirps.user_id)
+    AND (chirps.user_id = $4
+        OR NOT EXISTS (SELECT 1 FROM users authors WHERE authors.id = chirps.user_id AND authors.protected)
+        OR EXISTS (SELECT 1 FROM follows WHERE follows.follower_id = $4 AND follows.followee_id = chirps.user_id))
+))
+ORDER BY chirps.created_at ASC, chirps.id ASC
+LIMIT $5
+`
+
+type GetChirpThreadParams struct {
+	RootID   uuid.UUID
+	MaxDepth int32
+	ChirpID  uuid.UUID
+	ViewerID uuid.NullUUID
+	RowLimit int32
+}
+
+// Walks down at most max_depth replies from the root and returns the
+// row_limit earliest chirps, so a large conversation is never loaded whole.
+// Replies whose parent was deleted start where the thread places them: under
+// a placeholder for their parent, itself a reply to the root.
+func (q *Queries) GetChirpThread(ctx context.Context, arg GetChirpThreadParams) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, getChirpThread,
+		arg.RootID,
+		arg.MaxDepth,
+		arg.ChirpID,
+		arg.ViewerID,
+		arg.RowLimit,
+	)
 	if err != nil {
 		return nil, err
 	}
@@ -81,6 +201,13 @@ func (q *Queries) GetAllChirps(ctx context.Context) ([]Chirp, error) {
 			&i.UpdatedAt,
 			&i.Body,
 			&i.UserID,
+			&i.RevisionCount,
+			&i.ParentID,
+			&i.RootID,
+			&i.QuoteOf,
+			&i.LikeCount,
+			&i.SearchVector,
+			&i.FannedOut,
 		); err != nil {
 			return nil, err
 		}
@@ -95,14 +222,23 @@ func (q *Queries) GetAllChirps(ctx context.Context) ([]Chirp, error) {
 	return items, nil
 }
 
-const getAllChirpsFromAuthor = `-- name: GetAllChirpsFromAuthor :many
-SELECT id, created_at, updated_at, body, user_id FROM chirps
-WHERE user_id = $1
-ORDER BY created_at ASC
+const getVisibleChirpsByIDs = `-- name: GetVisibleChirpsByIDs :many
+SELECT id, created_at, updated_at, body, user_id, revision_count, parent_id, root_id, quote_of, like_count, search_vector, fanned_out FROM chirps
+WHERE id = ANY($1::uuid[])
+AND NOT EXISTS (SELECT 1 FROM blocks WHERE blocks.blocker_id = chirps.user_id AND blocks.blocked_id = $2)
+AND NOT EXISTS (SELECT 1 FROM mutes WHERE mutes.muter_id = $2 AND mutes.muted_id = chirps.user_id)
+AND (chirps.user_id = $2
+    OR NOT EXISTS (SELECT 1 FROM users authors WHERE authors.id = chirps.user_id AND authors.protected)
+    OR EXISTS (SELECT 1 FROM follows WHERE follows.follower_id = $2 AND follows.followee_id = chirps.user_id))
 `
 
-func (q *Queries) GetAllChirpsFromAuthor(ctx context.Context, userID uuid.UUID) ([]Chirp, error) {
-	rows, err := q.db.QueryContext(ctx, getAllChirpsFromAuthor, userID)
+type GetVisibleChirpsByIDsParams struct {
+	Ids      []uuid.UUID
+	ViewerID uuid.NullUUID
+}
+
+func (q *Queries) GetVisibleChirpsByIDs(ctx context.Context, arg GetVisibleChirpsByIDsParams) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, getVisibleChirpsByIDs, pq.Array(arg.Ids), arg.ViewerID)
 	if err != nil {
 		return nil, err
 	}
@@ -116,6 +252,13 @@ func (q *Queries) GetAllChirpsFromAuthor(ctx context.Context, userID uuid.UUID)
 			&i.UpdatedAt,
 			&i.Body,
 			&i.UserID,
+			&i.RevisionCount,
+			&i.ParentID,
+			&i.RootID,
+			&i.QuoteOf,
+			&i.LikeCount,
+			&i.SearchVector,
+			&i.FannedOut,
 		); err != nil {
 			return nil, err
 		}
@@ -130,13 +273,218 @@ func (q *Queries) GetAllChirpsFromAuthor(ctx context.Context, userID uuid.UUID)
 	return items, nil
 }
 
-const getChirpByID = `-- name: GetChirpByID :one
-SELECT id, created_at, updated_at, body, user_id FROM chirps
+const incrementChirpLikeCount = `-- name: IncrementChirpLikeCount :exec
+UPDATE chirps
+SET like_count = like_count + 1
 WHERE id = $1
 `
 
-func (q *Queries) GetChirpByID(ctx context.Context, id uuid.UUID) (Chirp, error) {
-	row := q.db.QueryRowContext(ctx, getChirpByID, id)
+func (q *Queries) IncrementChirpLikeCount(ctx context.Context, id uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, incrementChirpLikeCount, id)
+	return err
+}
+
+const listAllChirpsForBackfill = `-- name: ListAllChirpsForBackfill :many
+SELECT id, created_at, updated_at, body, user_id, revision_count, parent_id, root_id, quote_of, like_count, search_vector, fanned_out FROM chirps
+WHERE (NOT $1::boolean OR (created_at, id) > ($2::timestamp, $3::uuid))
+ORDER BY created_at ASC, id ASC
+LIMIT $4
+`
+
+type ListAllChirpsForBackfillParams struct {
+	HasCursor       bool
+	CursorCreatedAt time.Time
+	CursorID        uuid.UUID
+	RowLimit        int32
+}
+
+// Every chirp, whoever wrote it, for maintenance commands rather than feeds.
+func (q *Queries) ListAllChirpsForBackfill(ctx context.Context, arg ListAllChirpsForBackfillParams) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, listAllChirpsForBackfill,
+		arg.HasCursor,
+		arg.CursorCreatedAt,
+		arg.CursorID,
+		arg.RowLimit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Chirp
+	for rows.Next() {
+		var i Chirp
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Body,
+			&i.UserID,
+			&i.RevisionCount,
+			&i.ParentID,
+			&i.RootID,
+			&i.QuoteOf,
+			&i.LikeCount,
+			&i.SearchVector,
+			&i.FannedOut,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listChirpsAsc = `-- name: ListChirpsAsc :many
+SELECT id, created_at, updated_at, body, user_id, revision_count, parent_id, root_id, quote_of, like_count, search_vector, fanned_out FROM chirps
+WHERE (NOT $1::boolean OR (created_at, id) > ($2::timestamp, $3::uuid))
+AND NOT EXISTS (SELECT 1 FROM blocks WHERE blocks.blocker_id = chirps.user_id AND blocks.blocked_id = $4)
+AND NOT EXISTS (SELECT 1 FROM mutes WHERE mutes.muter_id = $4 AND mutes.muted_id = chirps.user_id)
+AND (chirps.user_id = $4
+    OR NOT EXISTS (SELECT 1 FROM users authors WHERE authors.id = chirps.user_id AND authors.protected)
+    OR EXISTS (SELECT 1 FROM follows WHERE follows.follower_id = $4 AND follows.followee_id = chirps.user_id))
+AND ($5::uuid IS NULL OR chirps.user_id IN (SELECT list_members.user_id FROM list_members WHERE list_members.list_id = $5))
+ORDER BY created_at ASC, id ASC
+LIMIT $6
+`
+
+type ListChirpsAscParams struct {
+	HasCursor       bool
+	CursorCreatedAt time.Time
+	CursorID        uuid.UUID
+	ViewerID        uuid.NullUUID
+	ListID          uuid.NullUUID
+	RowLimit        int32
+}
+
+func (q *Queries) ListChirpsAsc(ctx context.Context, arg ListChirpsAscParams) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, listChirpsAsc,
+		arg.HasCursor,
+		arg.CursorCreatedAt,
+		arg.CursorID,
+		arg.ViewerID,
+		arg.ListID,
+		arg.RowLimit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Chirp
+	for rows.Next() {
+		var i Chirp
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Body,
+			&i.UserID,
+			&i.RevisionCount,
+			&i.ParentID,
+			&i.RootID,
+			&i.QuoteOf,
+			&i.LikeCount,
+			&i.SearchVector,
+			&i.FannedOut,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listChirpsDesc = `-- name: ListChirpsDesc :many
+SELECT id, created_at, updated_at, body, user_id, revision_count, parent_id, root_id, quote_of, like_count, search_vector, fanned_out FROM chirps
+WHERE (NOT $1::boolean OR (created_at, id) < ($2::timestamp, $3::uuid))
+AND NOT EXISTS (SELECT 1 FROM blocks WHERE blocks.blocker_id = chirps.user_id AND blocks.blocked_id = $4)
+AND NOT EXISTS (SELECT 1 FROM mutes WHERE mutes.muter_id = $4 AND mutes.muted_id = chirps.user_id)
+AND (chirps.user_id = $4
+    OR NOT EXISTS (SELECT 1 FROM users authors WHERE authors.id = chirps.user_id AND authors.protected)
+    OR EXISTS (SELECT 1 FROM follows WHERE follows.follower_id = $4 AND follows.followee_id = chirps.user_id))
+AND ($5::uuid IS NULL OR chirps.user_id IN (SELECT list_members.user_id FROM list_members WHERE list_members.list_id = $5))
+ORDER BY created_at DESC, id DESC
+LIMIT $6
+`
+
+type ListChirpsDescParams struct {
+	HasCursor       bool
+	CursorCreatedAt time.Time
+	CursorID        uuid.UUID
+	ViewerID        uuid.NullUUID
+	ListID          uuid.NullUUID
+	RowLimit        int32
+}
+
+func (q *Queries) ListChirpsDesc(ctx context.Context, arg ListChirpsDescParams) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, listChirpsDesc,
+		arg.HasCursor,
+		arg.CursorCreatedAt,
+		arg.CursorID,
+		arg.ViewerID,
+		arg.ListID,
+		arg.RowLimit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Chirp
+	for rows.Next() {
+		var i Chirp
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Body,
+			&i.UserID,
+			&i.RevisionCount,
+			&i.ParentID,
+			&i.RootID,
+			&i.QuoteOf,
+			&i.LikeCount,
+			&i.SearchVector,
+			&i.FannedOut,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const updateChirpBody = `-- name: UpdateChirpBody :one
+UPDATE chirps
+SET body = $2, updated_at = $3, revision_count = revision_count + 1
+WHERE id = $1
+RETURNING id, created_at, updated_at, body, user_id, revision_count, parent_id, root_id, quote_of, like_count, search_vector, fanned_out
+`
+
+type UpdateChirpBodyParams struct {
+	ID        uuid.UUID
+	Body      string
+	UpdatedAt time.Time
+}
+
+func (q *Queries) UpdateChirpBody(ctx context.Context, arg UpdateChirpBodyParams) (Chirp, error) {
+	row := q.db.QueryRowContext(ctx, updateChirpBody, arg.ID, arg.Body, arg.UpdatedAt)
 	var i Chirp
 	err := row.Scan(
 		&i.ID,
@@ -144,6 +492,13 @@ func (q *Queries) GetChirpByID(ctx context.Context, id uuid.UUID) (Chirp, error)
 		&i.UpdatedAt,
 		&i.Body,
 		&i.UserID,
+		&i.RevisionCount,
+		&i.ParentID,
+		&i.RootID,
+		&i.QuoteOf,
+		&i.LikeCount,
+		&i.SearchVector,
+		&i.FannedOut,
 	)
 	return i, err
 }
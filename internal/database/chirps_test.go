@@ -0,0 +1,85 @@
+package database
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestGetChirpThread(t *testing.T) {
+	ctx := context.Background()
+	q := testQueries(t)
+	now := time.Now()
+
+	user, err := q.CreateUser(ctx, CreateUserParams{Email: "author@example.com", HashedPassword: "unset"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// root <- a <- b <- c
+	rootID := uuid.New()
+	ids := []uuid.UUID{rootID}
+	parent := uuid.NullUUID{}
+	for i := 0; i < 4; i++ {
+		id := rootID
+		if i > 0 {
+			id = uuid.New()
+			ids = append(ids, id)
+		}
+		_, err = q.CreateChirp(ctx, CreateChirpParams{
+			ID:        id,
+			CreatedAt: now.Add(time.Duration(i) * time.Second),
+			UpdatedAt: now,
+			Body:      "chirp",
+			UserID:    user.ID,
+			ParentID:  parent,
+			RootID:    rootID,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		parent = uuid.NullUUID{UUID: id, Valid: true}
+	}
+
+	thread := func(maxDepth, rowLimit int32) []uuid.UUID {
+		chirps, err := q.GetChirpThread(ctx, GetChirpThreadParams{
+			RootID:   rootID,
+			MaxDepth: maxDepth,
+			ChirpID:  rootID,
+			RowLimit: rowLimit,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		out := []uuid.UUID{}
+		for _, chirp := range chirps {
+			out = append(out, chirp.ID)
+		}
+		return out
+	}
+	check := func(name string, have []uuid.UUID, want ...uuid.UUID) {
+		if len(have) != len(want) {
+			t.Errorf("%s: have %d chirps want %d", name, len(have), len(want))
+			return
+		}
+		for i := range have {
+			if have[i] != want[i] {
+				t.Errorf("%s: chirp %d: have '%s' want '%s'", name, i, have[i], want[i])
+			}
+		}
+	}
+
+	check("whole thread", thread(10, 100), ids...)
+	check("depth", thread(2, 100), ids[:3]...)
+	check("row limit", thread(10, 2), ids[:2]...)
+
+	// b loses its parent, so it hangs off a placeholder on the root
+	err = q.DeleteChirp(ctx, ids[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	check("orphan", thread(2, 100), ids[0], ids[2])
+	check("orphan's reply", thread(3, 100), ids[0], ids[2], ids[3])
+}
@@ -6,26 +6,193 @@ package database
 
 import (
 	"database/sql"
+	"encoding/json"
 	"time"
 
 	"github.com/google/uuid"
 )
 
+type Block struct {
+	BlockerID uuid.UUID
+	BlockedID uuid.UUID
+	CreatedAt time.Time
+}
+
+type Bookmark struct {
+	UserID    uuid.UUID
+	ChirpID   uuid.UUID
+	CreatedAt time.Time
+}
+
 type Chirp struct {
+	ID            uuid.UUID
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+	Body          string
+	UserID        uuid.UUID
+	RevisionCount int32
+	ParentID      uuid.NullUUID
+	RootID        uuid.UUID
+	QuoteOf       uuid.NullUUID
+	LikeCount     int32
+	SearchVector  interface{}
+	FannedOut     bool
+}
+
+type ChirpFlag struct {
+	ID        uuid.UUID
+	CreatedAt time.Time
+	ChirpID   uuid.UUID
+	RuleID    uuid.NullUUID
+	RuleName  string
+	Reason    string
+}
+
+type ChirpRevision struct {
+	ID         uuid.UUID
+	ChirpID    uuid.UUID
+	Body       string
+	CreatedAt  time.Time
+	ReplacedAt time.Time
+}
+
+type ChirpTag struct {
+	ChirpID uuid.UUID
+	TagID   uuid.UUID
+}
+
+type ContentRule struct {
 	ID        uuid.UUID
 	CreatedAt time.Time
 	UpdatedAt time.Time
-	Body      string
+	Position  int32
+	Name      string
+	Kind      string
+	Action    string
+	Config    json.RawMessage
+	Enabled   bool
+}
+
+type Follow struct {
+	FollowerID uuid.UUID
+	FolloweeID uuid.UUID
+	CreatedAt  time.Time
+}
+
+type FollowRequest struct {
+	RequesterID uuid.UUID
+	TargetID    uuid.UUID
+	CreatedAt   time.Time
+}
+
+type Like struct {
+	UserID    uuid.UUID
+	ChirpID   uuid.UUID
+	CreatedAt time.Time
+}
+
+type List struct {
+	ID          uuid.UUID
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+	OwnerID     uuid.UUID
+	Name        string
+	Description string
+	Private     bool
+	MemberCount int32
+}
+
+type ListMember struct {
+	ListID    uuid.UUID
 	UserID    uuid.UUID
+	CreatedAt time.Time
+}
+
+type LoginThrottle struct {
+	Kind          string
+	Subject       string
+	Failures      int32
+	LastFailureAt time.Time
+	LockedUntil   sql.NullTime
+}
+
+type Medium struct {
+	ID                   uuid.UUID
+	CreatedAt            time.Time
+	UserID               uuid.UUID
+	ContentType          string
+	ByteSize             int32
+	Width                int32
+	Height               int32
+	StorageKey           string
+	ThumbnailKey         string
+	ThumbnailContentType string
+	ThumbnailWidth       int32
+	ThumbnailHeight      int32
+	Blurhash             string
+	ChirpID              uuid.NullUUID
+	Position             int32
+	AltText              string
+}
+
+type Mute struct {
+	MuterID   uuid.UUID
+	MutedID   uuid.UUID
+	CreatedAt time.Time
+}
+
+type PersonalAccessToken struct {
+	ID         uuid.UUID
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+	UserID     uuid.UUID
+	Name       string
+	TokenHash  string
+	Scopes     []string
+	ExpiresAt  sql.NullTime
+	LastUsedAt sql.NullTime
+}
+
+type Rechirp struct {
+	ID        uuid.UUID
+	CreatedAt time.Time
+	UserID    uuid.UUID
+	ChirpID   uuid.UUID
 }
 
 type RefreshToken struct {
-	Token     string
+	TokenHash   string
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+	UserID      uuid.UUID
+	ExpiresAt   time.Time
+	RevokedAt   sql.NullTime
+	FamilyID    uuid.UUID
+	RotatedAt   sql.NullTime
+	DeviceLabel string
+	UserAgent   string
+	IpAddress   string
+	LastUsedAt  time.Time
+}
+
+type SecurityNotification struct {
+	ID        uuid.UUID
 	CreatedAt time.Time
-	UpdatedAt time.Time
 	UserID    uuid.UUID
-	ExpiresAt time.Time
-	RevokedAt sql.NullTime
+	Kind      string
+	Message   string
+	IpAddress string
+}
+
+type Tag struct {
+	ID   uuid.UUID
+	Name string
+}
+
+type TimelineEntry struct {
+	UserID    uuid.UUID
+	ChirpID   uuid.UUID
+	CreatedAt time.Time
 }
 
 type User struct {
@@ -35,4 +202,14 @@ type User struct {
 	Email          string
 	HashedPassword string
 	IsChirpyRed    bool
+	IsAdmin        bool
+	FollowerCount  int32
+	FollowingCount int32
+	Protected      bool
+	Handle         sql.NullString
+	HandleSkeleton sql.NullString
+	DisplayName    string
+	Bio            string
+	Location       string
+	Website        string
 }
@@ -0,0 +1,138 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	_ "github.com/lib/pq"
+)
+
+// testQueries runs the migrations in a transaction on the database named by
+// TEST_DB_URL, which should be empty, and rolls everything back when the test
+// ends. Tests that need it are skipped without TEST_DB_URL.
+func testQueries(t *testing.T) *Queries {
+	t.Helper()
+	url := os.Getenv("TEST_DB_URL")
+	if url == "" {
+		t.Skip("TEST_DB_URL not set")
+	}
+	db, err := sql.Open("postgres", url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	tx, err := db.Begin()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { tx.Rollback() })
+
+	files, err := filepath.Glob("../../sql/schema/*.sql")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sort.Strings(files)
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		up, _, _ := strings.Cut(string(data), "-- +goose Down")
+		_, err = tx.Exec(up)
+		if err != nil {
+			t.Fatalf("%s: %s", filepath.Base(file), err)
+		}
+	}
+	return New(tx)
+}
+
+func TestAuthorTimelineHidesRechirps(t *testing.T) {
+	ctx := context.Background()
+	q := testQueries(t)
+	now := time.Now()
+
+	newUser := func(email string) uuid.UUID {
+		user, err := q.CreateUser(ctx, CreateUserParams{Email: email, HashedPassword: "unset"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return user.ID
+	}
+	author := newUser("author@example.com")
+	rechirper := newUser("rechirper@example.com")
+	follower := newUser("follower@example.com")
+	stranger := newUser("stranger@example.com")
+
+	chirpID := uuid.New()
+	_, err := q.CreateChirp(ctx, CreateChirpParams{
+		ID:        chirpID,
+		CreatedAt: now,
+		UpdatedAt: now,
+		Body:      "hello",
+		UserID:    author,
+		RootID:    chirpID,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = q.CreateRechirp(ctx, CreateRechirpParams{
+		ID:        uuid.New(),
+		CreatedAt: now,
+		UserID:    rechirper,
+		ChirpID:   chirpID,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rechirps := func(viewer uuid.UUID) int {
+		rows, err := q.ListAuthorTimelineDesc(ctx, ListAuthorTimelineDescParams{
+			AuthorID: rechirper,
+			ViewerID: uuid.NullUUID{UUID: viewer, Valid: true},
+			RowLimit: 10,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return len(rows)
+	}
+
+	if n := rechirps(stranger); n != 1 {
+		t.Fatalf("public rechirper: have %d entries want 1", n)
+	}
+
+	// A protected rechirper's rechirps are only shown to their followers
+	_, err = q.SetUserProtected(ctx, SetUserProtectedParams{ID: rechirper, Protected: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = q.CreateFollow(ctx, CreateFollowParams{FollowerID: follower, FolloweeID: rechirper, CreatedAt: now})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := rechirps(stranger); n != 0 {
+		t.Errorf("protected rechirper, stranger: have %d entries want 0", n)
+	}
+	if n := rechirps(follower); n != 1 {
+		t.Errorf("protected rechirper, follower: have %d entries want 1", n)
+	}
+	if n := rechirps(rechirper); n != 1 {
+		t.Errorf("protected rechirper, themselves: have %d entries want 1", n)
+	}
+
+	// Users blocked by the rechirper don't see their rechirps
+	_, err = q.CreateBlock(ctx, CreateBlockParams{BlockerID: rechirper, BlockedID: follower, CreatedAt: now})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := rechirps(follower); n != 0 {
+		t.Errorf("blocked by rechirper: have %d entries want 0", n)
+	}
+}
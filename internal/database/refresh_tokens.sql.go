@@ -14,86 +14,295 @@ import (
 )
 
 const getRefreshToken = `-- name: GetRefreshToken :one
-SELECT token, created_at, updated_at, user_id, expires_at, revoked_at FROM refresh_tokens
-WHERE token = $1
+SELECT token_hash, created_at, updated_at, user_id, expires_at, revoked_at, family_id, rotated_at, device_label, user_agent, ip_address, last_used_at FROM refresh_tokens
+WHERE token_hash = $1
 `
 
-func (q *Queries) GetRefreshToken(ctx context.Context, token string) (RefreshToken, error) {
-	row := q.db.QueryRowContext(ctx, getRefreshToken, token)
+func (q *Queries) GetRefreshToken(ctx context.Context, tokenHash string) (RefreshToken, error) {
+	row := q.db.QueryRowContext(ctx, getRefreshToken, tokenHash)
 	var i RefreshToken
 	err := row.Scan(
-		&i.Token,
+		&i.TokenHash,
 		&i.CreatedAt,
 		&i.UpdatedAt,
 		&i.UserID,
 		&i.ExpiresAt,
 		&i.RevokedAt,
+		&i.FamilyID,
+		&i.RotatedAt,
+		&i.DeviceLabel,
+		&i.UserAgent,
+		&i.IpAddress,
+		&i.LastUsedAt,
 	)
 	return i, err
 }
 
+const isSessionRevoked = `-- name: IsSessionRevoked :one
+SELECT EXISTS (
+    SELECT 1 FROM refresh_tokens
+    WHERE family_id = $1 AND revoked_at IS NOT NULL
+)
+`
+
+// Revoking a session revokes every token in its family.
+func (q *Queries) IsSessionRevoked(ctx context.Context, familyID uuid.UUID) (bool, error) {
+	row := q.db.QueryRowContext(ctx, isSessionRevoked, familyID)
+	var exists bool
+	err := row.Scan(&exists)
+	return exists, err
+}
+
+const listUserSessions = `-- name: ListUserSessions :many
+SELECT refresh_tokens.token_hash, refresh_tokens.created_at, refresh_tokens.updated_at, refresh_tokens.user_id, refresh_tokens.expires_at, refresh_tokens.revoked_at, refresh_tokens.family_id, refresh_tokens.rotated_at, refresh_tokens.device_label, refresh_tokens.user_agent, refresh_tokens.ip_address, refresh_tokens.last_used_at, (
+    SELECT min(family.created_at) FROM refresh_tokens AS family
+    WHERE family.family_id = refresh_tokens.family_id
+)::timestamp AS session_created_at
+FROM refresh_tokens
+WHERE refresh_tokens.user_id = $1
+AND refresh_tokens.rotated_at IS NULL
+AND refresh_tokens.revoked_at IS NULL
+AND refresh_tokens.expires_at > $2::timestamp
+ORDER BY refresh_tokens.last_used_at DESC
+`
+
+type ListUserSessionsParams struct {
+	UserID uuid.UUID
+	Now    time.Time
+}
+
+type ListUserSessionsRow struct {
+	RefreshToken     RefreshToken
+	SessionCreatedAt time.Time
+}
+
+// Lists the live token of each of a user's sessions, along with when the
+// session started.
+func (q *Queries) ListUserSessions(ctx context.Context, arg ListUserSessionsParams) ([]ListUserSessionsRow, error) {
+	rows, err := q.db.QueryContext(ctx, listUserSessions, arg.UserID, arg.Now)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListUserSessionsRow
+	for rows.Next() {
+		var i ListUserSessionsRow
+		if err := rows.Scan(
+			&i.RefreshToken.TokenHash,
+			&i.RefreshToken.CreatedAt,
+			&i.RefreshToken.UpdatedAt,
+			&i.RefreshToken.UserID,
+			&i.RefreshToken.ExpiresAt,
+			&i.RefreshToken.RevokedAt,
+			&i.RefreshToken.FamilyID,
+			&i.RefreshToken.RotatedAt,
+			&i.RefreshToken.DeviceLabel,
+			&i.RefreshToken.UserAgent,
+			&i.RefreshToken.IpAddress,
+			&i.RefreshToken.LastUsedAt,
+			&i.SessionCreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const revokeRefreshToken = `-- name: RevokeRefreshToken :one
 UPDATE refresh_tokens 
-SET revoked_at = $2, updated_at = $2 WHERE token = $1
-RETURNING token, created_at, updated_at, user_id, expires_at, revoked_at
+SET revoked_at = $2, updated_at = $2 WHERE token_hash = $1
+RETURNING token_hash, created_at, updated_at, user_id, expires_at, revoked_at, family_id, rotated_at, device_label, user_agent, ip_address, last_used_at
 `
 
 type RevokeRefreshTokenParams struct {
-	Token     string
+	TokenHash string
 	RevokedAt sql.NullTime
 }
 
 func (q *Queries) RevokeRefreshToken(ctx context.Context, arg RevokeRefreshTokenParams) (RefreshToken, error) {
-	row := q.db.QueryRowContext(ctx, revokeRefreshToken, arg.Token, arg.RevokedAt)
+	row := q.db.QueryRowContext(ctx, revokeRefreshToken, arg.TokenHash, arg.RevokedAt)
+	var i RefreshToken
+	err := row.Scan(
+		&i.TokenHash,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.UserID,
+		&i.ExpiresAt,
+		&i.RevokedAt,
+		&i.FamilyID,
+		&i.RotatedAt,
+		&i.DeviceLabel,
+		&i.UserAgent,
+		&i.IpAddress,
+		&i.LastUsedAt,
+	)
+	return i, err
+}
+
+const revokeRefreshTokenFamily = `-- name: RevokeRefreshTokenFamily :exec
+UPDATE refresh_tokens
+SET revoked_at = $1, updated_at = $1
+WHERE family_id = $2
+AND revoked_at IS NULL
+`
+
+type RevokeRefreshTokenFamilyParams struct {
+	RevokedAt sql.NullTime
+	FamilyID  uuid.UUID
+}
+
+func (q *Queries) RevokeRefreshTokenFamily(ctx context.Context, arg RevokeRefreshTokenFamilyParams) error {
+	_, err := q.db.ExecContext(ctx, revokeRefreshTokenFamily, arg.RevokedAt, arg.FamilyID)
+	return err
+}
+
+const revokeUserSession = `-- name: RevokeUserSession :execrows
+UPDATE refresh_tokens
+SET revoked_at = $1, updated_at = $1
+WHERE family_id = $2
+AND user_id = $3
+AND revoked_at IS NULL
+`
+
+type RevokeUserSessionParams struct {
+	RevokedAt sql.NullTime
+	FamilyID  uuid.UUID
+	UserID    uuid.UUID
+}
+
+func (q *Queries) RevokeUserSession(ctx context.Context, arg RevokeUserSessionParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, revokeUserSession, arg.RevokedAt, arg.FamilyID, arg.UserID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const revokeUserSessions = `-- name: RevokeUserSessions :execrows
+UPDATE refresh_tokens
+SET revoked_at = $1, updated_at = $1
+WHERE user_id = $2
+AND revoked_at IS NULL
+AND ($3::uuid IS NULL OR family_id <> $3::uuid)
+`
+
+type RevokeUserSessionsParams struct {
+	RevokedAt      sql.NullTime
+	UserID         uuid.UUID
+	ExceptFamilyID uuid.NullUUID
+}
+
+// Revokes all of a user's sessions, except except_family_id if given.
+func (q *Queries) RevokeUserSessions(ctx context.Context, arg RevokeUserSessionsParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, revokeUserSessions, arg.RevokedAt, arg.UserID, arg.ExceptFamilyID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const rotateRefreshToken = `-- name: RotateRefreshToken :one
+UPDATE refresh_tokens
+SET rotated_at = $1::timestamp, updated_at = $1::timestamp
+WHERE token_hash = $2
+AND rotated_at IS NULL
+AND revoked_at IS NULL
+AND expires_at > $1::timestamp
+RETURNING token_hash, created_at, updated_at, user_id, expires_at, revoked_at, family_id, rotated_at, device_label, user_agent, ip_address, last_used_at
+`
+
+type RotateRefreshTokenParams struct {
+	Now       time.Time
+	TokenHash string
+}
+
+// Retires a token that is still usable. Of two refreshes racing with the
+// same token, only one gets a row back.
+func (q *Queries) RotateRefreshToken(ctx context.Context, arg RotateRefreshTokenParams) (RefreshToken, error) {
+	row := q.db.QueryRowContext(ctx, rotateRefreshToken, arg.Now, arg.TokenHash)
 	var i RefreshToken
 	err := row.Scan(
-		&i.Token,
+		&i.TokenHash,
 		&i.CreatedAt,
 		&i.UpdatedAt,
 		&i.UserID,
 		&i.ExpiresAt,
 		&i.RevokedAt,
+		&i.FamilyID,
+		&i.RotatedAt,
+		&i.DeviceLabel,
+		&i.UserAgent,
+		&i.IpAddress,
+		&i.LastUsedAt,
 	)
 	return i, err
 }
 
 const storeRefreshToken = `-- name: StoreRefreshToken :one
-INSERT INTO refresh_tokens (token, created_at, updated_at, user_id, expires_at, revoked_at)
+INSERT INTO refresh_tokens (token_hash, created_at, updated_at, user_id, expires_at, revoked_at, family_id, device_label, user_agent, ip_address, last_used_at)
 VALUES (
     $1,
     $2,
     $3,
     $4,
     $5,
-    NULL
+    NULL,
+    $6,
+    $7,
+    $8,
+    $9,
+    $10
 )
-RETURNING token, created_at, updated_at, user_id, expires_at, revoked_at
+RETURNING token_hash, created_at, updated_at, user_id, expires_at, revoked_at, family_id, rotated_at, device_label, user_agent, ip_address, last_used_at
 `
 
 type StoreRefreshTokenParams struct {
-	Token     string
-	CreatedAt time.Time
-	UpdatedAt time.Time
-	UserID    uuid.UUID
-	ExpiresAt time.Time
+	TokenHash   string
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+	UserID      uuid.UUID
+	ExpiresAt   time.Time
+	FamilyID    uuid.UUID
+	DeviceLabel string
+	UserAgent   string
+	IpAddress   string
+	LastUsedAt  time.Time
 }
 
 func (q *Queries) StoreRefreshToken(ctx context.Context, arg StoreRefreshTokenParams) (RefreshToken, error) {
 	row := q.db.QueryRowContext(ctx, storeRefreshToken,
-		arg.Token,
+		arg.TokenHash,
 		arg.CreatedAt,
 		arg.UpdatedAt,
 		arg.UserID,
 		arg.ExpiresAt,
+		arg.FamilyID,
+		arg.DeviceLabel,
+		arg.UserAgent,
+		arg.IpAddress,
+		arg.LastUsedAt,
 	)
 	var i RefreshToken
 	err := row.Scan(
-		&i.Token,
+		&i.TokenHash,
 		&i.CreatedAt,
 		&i.UpdatedAt,
 		&i.UserID,
 		&i.ExpiresAt,
 		&i.RevokedAt,
+		&i.FamilyID,
+		&i.RotatedAt,
+		&i.DeviceLabel,
+		&i.UserAgent,
+		&i.IpAddress,
+		&i.LastUsedAt,
 	)
 	return i, err
 }
@@ -0,0 +1,68 @@
+package database
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestTimelineFollowsFanOutChoice(t *testing.T) {
+	ctx := context.Background()
+	q := testQueries(t)
+	now := time.Now()
+
+	newUser := func(email string) uuid.UUID {
+		user, err := q.CreateUser(ctx, CreateUserParams{Email: email, HashedPassword: "unset"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return user.ID
+	}
+	author := newUser("author@example.com")
+	follower := newUser("follower@example.com")
+
+	// One chirp posted while the author was fanned out and one posted while
+	// they weren't. Which source each is read from mustn't depend on the
+	// author's follower count now.
+	fanned, pulled := uuid.New(), uuid.New()
+	for i, id := range []uuid.UUID{fanned, pulled} {
+		_, err := q.CreateChirp(ctx, CreateChirpParams{
+			ID:        id,
+			CreatedAt: now.Add(time.Duration(i) * time.Second),
+			UpdatedAt: now,
+			Body:      "chirp",
+			UserID:    author,
+			RootID:    id,
+			FannedOut: id == fanned,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	_, err := q.CreateFollow(ctx, CreateFollowParams{FollowerID: follower, FolloweeID: author, CreatedAt: now})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = q.BackfillTimeline(ctx, BackfillTimelineParams{UserID: follower, AuthorID: author, RowLimit: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	viewer := uuid.NullUUID{UUID: follower, Valid: true}
+	inbox, err := q.ListInboxChirps(ctx, ListInboxChirpsParams{UserID: follower, ViewerID: viewer, RowLimit: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(inbox) != 1 || inbox[0].ID != fanned {
+		t.Errorf("inbox: have %d chirps want only the fanned out one", len(inbox))
+	}
+	merged, err := q.ListPulledChirps(ctx, ListPulledChirpsParams{UserID: follower, ViewerID: viewer, RowLimit: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(merged) != 1 || merged[0].ID != pulled {
+		t.Errorf("pulled: have %d chirps want only the one not fanned out", len(merged))
+	}
+}
@@ -7,6 +7,7 @@ package database
 
 import (
 	"context"
+	"database/sql"
 
 	"github.com/google/uuid"
 )
@@ -20,7 +21,7 @@ VALUES (
     $1,
     $2
 )
-RETURNING id, created_at, updated_at, email, hashed_password, is_chirpy_red
+RETURNING id, created_at, updated_at, email, hashed_password, is_chirpy_red, is_admin, follower_count, following_count, protected, handle, handle_skeleton, display_name, bio, location, website
 `
 
 type CreateUserParams struct {
@@ -38,10 +39,37 @@ func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (User, e
 		&i.Email,
 		&i.HashedPassword,
 		&i.IsChirpyRed,
+		&i.IsAdmin,
+		&i.FollowerCount,
+		&i.FollowingCount,
+		&i.Protected,
+		&i.Handle,
+		&i.HandleSkeleton,
+		&i.DisplayName,
+		&i.Bio,
+		&i.Location,
+		&i.Website,
 	)
 	return i, err
 }
 
+const decrementFollowCounts = `-- name: DecrementFollowCounts :exec
+UPDATE users
+SET follower_count = follower_count - CASE WHEN id = $1::uuid THEN 1 ELSE 0 END,
+    following_count = following_count - CASE WHEN id = $2::uuid THEN 1 ELSE 0 END
+WHERE id IN ($2, $1)
+`
+
+type DecrementFollowCountsParams struct {
+	FolloweeID uuid.UUID
+	FollowerID uuid.UUID
+}
+
+func (q *Queries) DecrementFollowCounts(ctx context.Context, arg DecrementFollowCountsParams) error {
+	_, err := q.db.ExecContext(ctx, decrementFollowCounts, arg.FolloweeID, arg.FollowerID)
+	return err
+}
+
 const deleteAllUsers = `-- name: DeleteAllUsers :exec
 DELETE FROM users
 `
@@ -52,7 +80,7 @@ func (q *Queries) DeleteAllUsers(ctx context.Context) error {
 }
 
 const getUserByEmail = `-- name: GetUserByEmail :one
-SELECT id, created_at, updated_at, email, hashed_password, is_chirpy_red FROM users
+SELECT id, created_at, updated_at, email, hashed_password, is_chirpy_red, is_admin, follower_count, following_count, protected, handle, handle_skeleton, display_name, bio, location, website FROM users
 WHERE email = $1
 `
 
@@ -66,12 +94,51 @@ func (q *Queries) GetUserByEmail(ctx context.Context, email string) (User, error
 		&i.Email,
 		&i.HashedPassword,
 		&i.IsChirpyRed,
+		&i.IsAdmin,
+		&i.FollowerCount,
+		&i.FollowingCount,
+		&i.Protected,
+		&i.Handle,
+		&i.HandleSkeleton,
+		&i.DisplayName,
+		&i.Bio,
+		&i.Location,
+		&i.Website,
+	)
+	return i, err
+}
+
+const getUserByHandle = `-- name: GetUserByHandle :one
+SELECT id, created_at, updated_at, email, hashed_password, is_chirpy_red, is_admin, follower_count, following_count, protected, handle, handle_skeleton, display_name, bio, location, website FROM users
+WHERE lower(handle) = lower($1::text)
+`
+
+func (q *Queries) GetUserByHandle(ctx context.Context, handle string) (User, error) {
+	row := q.db.QueryRowContext(ctx, getUserByHandle, handle)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Email,
+		&i.HashedPassword,
+		&i.IsChirpyRed,
+		&i.IsAdmin,
+		&i.FollowerCount,
+		&i.FollowingCount,
+		&i.Protected,
+		&i.Handle,
+		&i.HandleSkeleton,
+		&i.DisplayName,
+		&i.Bio,
+		&i.Location,
+		&i.Website,
 	)
 	return i, err
 }
 
 const getUserById = `-- name: GetUserById :one
-SELECT id, created_at, updated_at, email, hashed_password, is_chirpy_red FROM users
+SELECT id, created_at, updated_at, email, hashed_password, is_chirpy_red, is_admin, follower_count, following_count, protected, handle, handle_skeleton, display_name, bio, location, website FROM users
 WHERE id = $1
 `
 
@@ -85,6 +152,68 @@ func (q *Queries) GetUserById(ctx context.Context, id uuid.UUID) (User, error) {
 		&i.Email,
 		&i.HashedPassword,
 		&i.IsChirpyRed,
+		&i.IsAdmin,
+		&i.FollowerCount,
+		&i.FollowingCount,
+		&i.Protected,
+		&i.Handle,
+		&i.HandleSkeleton,
+		&i.DisplayName,
+		&i.Bio,
+		&i.Location,
+		&i.Website,
+	)
+	return i, err
+}
+
+const incrementFollowCounts = `-- name: IncrementFollowCounts :exec
+UPDATE users
+SET follower_count = follower_count + CASE WHEN id = $1::uuid THEN 1 ELSE 0 END,
+    following_count = following_count + CASE WHEN id = $2::uuid THEN 1 ELSE 0 END
+WHERE id IN ($2, $1)
+`
+
+type IncrementFollowCountsParams struct {
+	FolloweeID uuid.UUID
+	FollowerID uuid.UUID
+}
+
+func (q *Queries) IncrementFollowCounts(ctx context.Context, arg IncrementFollowCountsParams) error {
+	_, err := q.db.ExecContext(ctx, incrementFollowCounts, arg.FolloweeID, arg.FollowerID)
+	return err
+}
+
+const setUserProtected = `-- name: SetUserProtected :one
+UPDATE users
+SET protected = $2, updated_at = NOW() WHERE id = $1
+RETURNING id, created_at, updated_at, email, hashed_password, is_chirpy_red, is_admin, follower_count, following_count, protected, handle, handle_skeleton, display_name, bio, location, website
+`
+
+type SetUserProtectedParams struct {
+	ID        uuid.UUID
+	Protected bool
+}
+
+func (q *Queries) SetUserProtected(ctx context.Context, arg SetUserProtectedParams) (User, error) {
+	row := q.db.QueryRowContext(ctx, setUserProtected, arg.ID, arg.Protected)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Email,
+		&i.HashedPassword,
+		&i.IsChirpyRed,
+		&i.IsAdmin,
+		&i.FollowerCount,
+		&i.FollowingCount,
+		&i.Protected,
+		&i.Handle,
+		&i.HandleSkeleton,
+		&i.DisplayName,
+		&i.Bio,
+		&i.Location,
+		&i.Website,
 	)
 	return i, err
 }
@@ -92,7 +221,7 @@ func (q *Queries) GetUserById(ctx context.Context, id uuid.UUID) (User, error) {
 const setUserSubscription = `-- name: SetUserSubscription :one
 UPDATE users
 SET is_chirpy_red = $2 WHERE id = $1
-RETURNING id, created_at, updated_at, email, hashed_password, is_chirpy_red
+RETURNING id, created_at, updated_at, email, hashed_password, is_chirpy_red, is_admin, follower_count, following_count, protected, handle, handle_skeleton, display_name, bio, location, website
 `
 
 type SetUserSubscriptionParams struct {
@@ -110,6 +239,16 @@ func (q *Queries) SetUserSubscription(ctx context.Context, arg SetUserSubscripti
 		&i.Email,
 		&i.HashedPassword,
 		&i.IsChirpyRed,
+		&i.IsAdmin,
+		&i.FollowerCount,
+		&i.FollowingCount,
+		&i.Protected,
+		&i.Handle,
+		&i.HandleSkeleton,
+		&i.DisplayName,
+		&i.Bio,
+		&i.Location,
+		&i.Website,
 	)
 	return i, err
 }
@@ -117,7 +256,7 @@ func (q *Queries) SetUserSubscription(ctx context.Context, arg SetUserSubscripti
 const updateUser = `-- name: UpdateUser :one
 UPDATE users 
 SET email = $2, hashed_password = $3, updated_at = NOW() WHERE id = $1
-RETURNING id, created_at, updated_at, email, hashed_password, is_chirpy_red
+RETURNING id, created_at, updated_at, email, hashed_password, is_chirpy_red, is_admin, follower_count, following_count, protected, handle, handle_skeleton, display_name, bio, location, website
 `
 
 type UpdateUserParams struct {
@@ -136,6 +275,71 @@ func (q *Queries) UpdateUser(ctx context.Context, arg UpdateUserParams) (User, e
 		&i.Email,
 		&i.HashedPassword,
 		&i.IsChirpyRed,
+		&i.IsAdmin,
+		&i.FollowerCount,
+		&i.FollowingCount,
+		&i.Protected,
+		&i.Handle,
+		&i.HandleSkeleton,
+		&i.DisplayName,
+		&i.Bio,
+		&i.Location,
+		&i.Website,
+	)
+	return i, err
+}
+
+const updateUserProfile = `-- name: UpdateUserProfile :one
+UPDATE users
+SET handle = COALESCE($1, handle),
+    handle_skeleton = COALESCE($2, handle_skeleton),
+    display_name = COALESCE($3, display_name),
+    bio = COALESCE($4, bio),
+    location = COALESCE($5, location),
+    website = COALESCE($6, website),
+    updated_at = NOW()
+WHERE id = $7
+RETURNING id, created_at, updated_at, email, hashed_password, is_chirpy_red, is_admin, follower_count, following_count, protected, handle, handle_skeleton, display_name, bio, location, website
+`
+
+type UpdateUserProfileParams struct {
+	Handle         sql.NullString
+	HandleSkeleton sql.NullString
+	DisplayName    sql.NullString
+	Bio            sql.NullString
+	Location       sql.NullString
+	Website        sql.NullString
+	ID             uuid.UUID
+}
+
+func (q *Queries) UpdateUserProfile(ctx context.Context, arg UpdateUserProfileParams) (User, error) {
+	row := q.db.QueryRowContext(ctx, updateUserProfile,
+		arg.Handle,
+		arg.HandleSkeleton,
+		arg.DisplayName,
+		arg.Bio,
+		arg.Location,
+		arg.Website,
+		arg.ID,
+	)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Email,
+		&i.HashedPassword,
+		&i.IsChirpyRed,
+		&i.IsAdmin,
+		&i.FollowerCount,
+		&i.FollowingCount,
+		&i.Protected,
+		&i.Handle,
+		&i.HandleSkeleton,
+		&i.DisplayName,
+		&i.Bio,
+		&i.Location,
+		&i.Website,
 	)
 	return i, err
 }
@@ -0,0 +1,143 @@
+// Package handles validates user handles and reduces them to a skeleton, a
+// form in which handles that look alike are equal. Two users may not have
+// handles with the same skeleton, which keeps "paypal", "PaypaI" and a
+// "pаypal" spelled with a Cyrillic а from all being registered.
+package handles
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+const (
+	MinLength = 3
+	MaxLength = 20
+)
+
+// reserved are handles nobody can take, compared by skeleton. They're
+// either routes of the site or names that suggest an official account.
+var reserved = []string{
+	"about", "admin", "administrator", "api", "app", "everyone", "help",
+	"here", "login", "logout", "me", "mod", "moderator", "null", "official",
+	"polka", "privacy", "profile", "protected", "register", "root",
+	"search", "security", "settings", "signup", "staff", "support",
+	"system", "terms", "timeline", "undefined",
+}
+
+// brand may not appear anywhere in a handle.
+const brand = "chirpy"
+
+// scripts are the writing systems a handle may use. A handle's letters must
+// all come from one of them, since mixing scripts is the usual way to fake
+// another user's handle. Chinese and Japanese characters count as one
+// script because Japanese mixes them.
+var scripts = []struct {
+	name   string
+	tables []*unicode.RangeTable
+}{
+	{"Latin", []*unicode.RangeTable{unicode.Latin}},
+	{"Cyrillic", []*unicode.RangeTable{unicode.Cyrillic}},
+	{"Greek", []*unicode.RangeTable{unicode.Greek}},
+	{"Armenian", []*unicode.RangeTable{unicode.Armenian}},
+	{"Hebrew", []*unicode.RangeTable{unicode.Hebrew}},
+	{"Arabic", []*unicode.RangeTable{unicode.Arabic}},
+	{"Devanagari", []*unicode.RangeTable{unicode.Devanagari}},
+	{"Thai", []*unicode.RangeTable{unicode.Thai}},
+	{"Hangul", []*unicode.RangeTable{unicode.Hangul}},
+	{"Han", []*unicode.RangeTable{unicode.Han, unicode.Hiragana, unicode.Katakana}},
+}
+
+// confusables maps lowercase letters that look like a Latin letter to that
+// letter. It covers the lookalikes from other scripts that are used for
+// impersonation in practice rather than the full Unicode confusables list.
+var confusables = map[rune]rune{
+	// Cyrillic
+	'а': 'a', 'с': 'c', 'ԁ': 'd', 'е': 'e', 'ё': 'e', 'һ': 'h', 'і': 'i',
+	'ї': 'i', 'ј': 'j', 'к': 'k', 'ӏ': 'l', 'о': 'o', 'р': 'p', 'ԛ': 'q',
+	'ѕ': 's', 'у': 'y', 'ү': 'y', 'х': 'x', 'ԝ': 'w',
+	// Greek
+	'α': 'a', 'ε': 'e', 'ι': 'i', 'κ': 'k', 'ν': 'v', 'ο': 'o', 'ρ': 'p',
+	'τ': 't', 'υ': 'u', 'χ': 'x', 'ω': 'w', 'γ': 'y',
+	// Latin lookalikes of other Latin letters
+	'ı': 'i', 'ɑ': 'a', 'ɡ': 'g', 'ℓ': 'l',
+	// Digits that pass for letters
+	'0': 'o', '1': 'l',
+}
+
+// sequences are runs of letters that read as a single letter.
+var sequences = strings.NewReplacer("rn", "m", "vv", "w")
+
+// Validate checks that handle is an acceptable handle: MinLength to MaxLength
+// letters, digits or underscores, with all letters from one script, and not
+// reserved.
+func Validate(handle string) error {
+	length := utf8.RuneCountInString(handle)
+	if length < MinLength || length > MaxLength {
+		return fmt.Errorf("handle must be between %d and %d characters", MinLength, MaxLength)
+	}
+
+	script := ""
+	for _, r := range handle {
+		switch {
+		case r == '_' || ('0' <= r && r <= '9'):
+			continue
+		case !unicode.IsLetter(r):
+			return fmt.Errorf("handle may only contain letters, digits and underscores")
+		}
+		name := scriptOf(r)
+		if name == "" {
+			return fmt.Errorf("handle contains an unsupported character %q", r)
+		}
+		if script != "" && name != script {
+			return fmt.Errorf("handle mixes %s and %s letters", script, name)
+		}
+		script = name
+	}
+
+	// A capital I reads as either an i or an l, so check both readings
+	for _, skeleton := range []string{Skeleton(handle), Skeleton(strings.ToLower(handle))} {
+		if strings.Contains(skeleton, brand) {
+			return fmt.Errorf("handle is reserved")
+		}
+		for _, word := range reserved {
+			if skeleton == Skeleton(word) {
+				return fmt.Errorf("handle is reserved")
+			}
+		}
+	}
+	return nil
+}
+
+// Skeleton reduces a handle to the form used to compare handles for
+// lookalikes. It ignores case, folds fullwidth forms to ASCII, and maps
+// confusable characters to the Latin letter they resemble.
+func Skeleton(handle string) string {
+	var b strings.Builder
+	for _, r := range handle {
+		// Fullwidth ASCII, as in "ｐａｙｐａｌ"
+		if 0xFF01 <= r && r <= 0xFF5E {
+			r -= 0xFEE0
+		}
+		// A capital I is indistinguishable from a lowercase l in many fonts
+		if r == 'I' {
+			r = 'l'
+		}
+		r = unicode.ToLower(r)
+		if mapped, ok := confusables[r]; ok {
+			r = mapped
+		}
+		b.WriteRune(r)
+	}
+	return sequences.Replace(b.String())
+}
+
+func scriptOf(r rune) string {
+	for _, script := range scripts {
+		if unicode.In(r, script.tables...) {
+			return script.name
+		}
+	}
+	return ""
+}
@@ -0,0 +1,61 @@
+package handles
+
+import "testing"
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		handle string
+		valid  bool
+	}{
+		{"jthughes", true},
+		{"J_T_Hughes99", true},
+		{"ab", false},
+		{"abcdefghijklmnopqrstu", false},
+		{"has space", false},
+		{"dash-name", false},
+		{"zero\u200dwidth", false},
+		{"\u00e9clair", true},
+		{"e\u0301clair", false},
+		{"иван", true},
+		{"δημήτρης", true},
+		{"たなか太郎", true},
+		{"pаypal", false},
+		{"admin", false},
+		{"ADMIN", false},
+		{"AdmIn", false},
+		{"settings", false},
+		{"chirpy_support", false},
+		{"Chirpy", false},
+		{"badminton", true},
+	}
+	for _, test := range tests {
+		err := Validate(test.handle)
+		if (err == nil) != test.valid {
+			t.Errorf("Validate(%q): have error '%v' want valid %v", test.handle, err, test.valid)
+		}
+	}
+}
+
+func TestSkeleton(t *testing.T) {
+	same := []string{
+		"paypal",
+		"PayPal",
+		"PaypaI",
+		"paypa1",
+		"pаypаl",
+		"ｐａｙｐａｌ",
+	}
+	want := Skeleton(same[0])
+	for _, handle := range same[1:] {
+		if result := Skeleton(handle); result != want {
+			t.Errorf("Skeleton(%q): have '%s' want '%s'", handle, result, want)
+		}
+	}
+
+	if Skeleton("modern") != Skeleton("modem") {
+		t.Errorf("Skeleton: expected 'modern' to match 'modem'")
+	}
+	if Skeleton("alice") == Skeleton("bob") {
+		t.Errorf("Skeleton: expected 'alice' and 'bob' to differ")
+	}
+}
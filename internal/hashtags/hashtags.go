@@ -0,0 +1,88 @@
+package hashtags
+
+import (
+	"context"
+	"strings"
+	"unicode"
+
+	"github.com/google/uuid"
+	"github.com/jthughes/chirpynetwork/internal/database"
+	"golang.org/x/text/cases"
+	"golang.org/x/text/unicode/norm"
+)
+
+const maxTagLength = 100
+
+// Normalize folds a tag to the form it is stored and matched in. A leading
+// '#' is dropped so that both "#Go" and "go" normalize to "go". Case is
+// folded rather than lowered, so "#Straße" matches "#STRASSE", and the result
+// is in NFC, so accents typed precomposed or as combining marks match.
+func Normalize(tag string) string {
+	return norm.NFC.String(cases.Fold().String(strings.TrimPrefix(tag, "#")))
+}
+
+func isTagRune(r rune) bool {
+	return r == '_' || unicode.IsLetter(r) || unicode.IsNumber(r) || unicode.IsMark(r)
+}
+
+// Extract returns the normalized hashtags in body, each once, in the order
+// they first appear. A hashtag is a '#' that doesn't follow another word
+// character, followed by letters, numbers, underscores or combining marks,
+// such as the vowel signs of Indic scripts, at least one of which is a
+// letter.
+func Extract(body string) []string {
+	tags := []string{}
+	seen := map[string]bool{}
+	runes := []rune(body)
+	for i := 0; i < len(runes); i++ {
+		if runes[i] != '#' || (i > 0 && (isTagRune(runes[i-1]) || runes[i-1] == '#')) {
+			continue
+		}
+		end := i + 1
+		hasLetter := false
+		for end < len(runes) && isTagRune(runes[end]) {
+			if unicode.IsLetter(runes[end]) {
+				hasLetter = true
+			}
+			end++
+		}
+		tag := string(runes[i+1 : end])
+		i = end - 1
+		if !hasLetter || len(tag) > maxTagLength {
+			continue
+		}
+		tag = Normalize(tag)
+		if !seen[tag] {
+			seen[tag] = true
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
+
+// Replace sets the tags of a chirp to those found in body, dropping any it
+// had before. Callers editing a chirp should run it in the same transaction
+// as the edit.
+func Replace(ctx context.Context, db *database.Queries, chirpID uuid.UUID, body string) error {
+	err := db.DeleteChirpTags(ctx, chirpID)
+	if err != nil {
+		return err
+	}
+	for _, name := range Extract(body) {
+		tag, err := db.UpsertTag(ctx, database.UpsertTagParams{
+			ID:   uuid.New(),
+			Name: name,
+		})
+		if err != nil {
+			return err
+		}
+		err = db.CreateChirpTag(ctx, database.CreateChirpTagParams{
+			ChirpID: chirpID,
+			TagID:   tag.ID,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,51 @@
+package hashtags
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestExtract(t *testing.T) {
+	tests := []struct {
+		body string
+		want []string
+	}{
+		{"no tags here", []string{}},
+		{"#Go is fun", []string{"go"}},
+		{"ending with #golang", []string{"golang"}},
+		{"#GO and #go and #Go", []string{"go"}},
+		{"two #one,#two", []string{"one", "two"}},
+		{"numbers #1 #2024 are not tags but #web3 is", []string{"web3"}},
+		{"email@host#frag and a##double", []string{}},
+		{"unicode #Café #日本語", []string{"café", "日本語"}},
+		{"indic #हिन्दी #বাংলা #தமிழ்!", []string{"हिन्दी", "বাংলা", "தமிழ்"}},
+		{"snake #snake_case.", []string{"snake_case"}},
+		{"#", []string{}},
+	}
+	for _, test := range tests {
+		result := Extract(test.body)
+		if !slices.Equal(result, test.want) {
+			t.Errorf("Extract(%q): have '%v' want '%v'", test.body, result, test.want)
+		}
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	if result := Normalize("#GoLang"); result != "golang" {
+		t.Fatalf("Normalize mismatch: have '%s' want 'golang'", result)
+	}
+
+	tests := []struct {
+		name string
+		a, b string
+	}{
+		{"precomposed and combining accents", "#Caf\u00e9", "#Cafe\u0301"},
+		{"case folding", "#Stra\u00dfe", "#STRASSE"},
+		{"final sigma", "#\u039f\u0394\u039f\u03a3", "#\u03bf\u03b4\u03bf\u03c2"},
+	}
+	for _, test := range tests {
+		if a, b := Normalize(test.a), Normalize(test.b); a != b {
+			t.Errorf("%s: '%s' and '%s' normalize differently", test.name, a, b)
+		}
+	}
+}
@@ -0,0 +1,48 @@
+package media
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+func TestBlurhash(t *testing.T) {
+	fill := func(c color.RGBA) *image.RGBA {
+		img := image.NewRGBA(image.Rect(0, 0, 8, 6))
+		for y := 0; y < 6; y++ {
+			for x := 0; x < 8; x++ {
+				img.SetRGBA(x, y, c)
+			}
+		}
+		return img
+	}
+
+	// The hash starts with the component counts, then after the AC scale
+	// comes the average colour
+	tests := []struct {
+		name    string
+		img     *image.RGBA
+		average string
+	}{
+		{"white", fill(color.RGBA{255, 255, 255, 255}), "TSUA"},
+		{"black", fill(color.RGBA{0, 0, 0, 255}), "0000"},
+		{"red", fill(color.RGBA{255, 0, 0, 255}), "TI:j"},
+	}
+	for _, test := range tests {
+		hash := Blurhash(test.img)
+		if len(hash) != 28 {
+			t.Errorf("%s: have length %d want 28", test.name, len(hash))
+			continue
+		}
+		if hash[0] != 'L' || hash[2:6] != test.average {
+			t.Errorf("%s: have '%s' want 'L?%s...'", test.name, hash, test.average)
+		}
+	}
+
+	if got := encode83(0xFFFFFF, 4); got != "TSUA" {
+		t.Errorf("encode83: have '%s' want 'TSUA'", got)
+	}
+	if got := Blurhash(image.NewRGBA(image.Rect(0, 0, 0, 0))); got != "" {
+		t.Errorf("empty image: have '%s' want ''", got)
+	}
+}
@@ -0,0 +1,281 @@
+package media
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/draw"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"net/http"
+)
+
+const (
+	// MaxPixels bounds the decoded size of an upload, both the whole image
+	// and, for an animation, every frame added up, so a small file can't
+	// expand into a huge image. An
+	// image this size takes up to 64MB once decoded, and about as much
+	// again if it has to be turned upright.
+	MaxPixels = 4096 * 4096
+	// ThumbnailSize is the longest side of a thumbnail in pixels.
+	ThumbnailSize = 400
+
+	jpegQuality = 90
+)
+
+var (
+	ErrUnsupportedType = errors.New("unsupported media type")
+	ErrTooManyPixels   = errors.New("image dimensions are too large")
+)
+
+// extensions maps the content types that can be uploaded, as named by
+// http.DetectContentType, to the file extension they are stored with.
+var extensions = map[string]string{
+	"image/jpeg": "jpg",
+	"image/png":  "png",
+	"image/gif":  "gif",
+}
+
+// Extension returns the file extension media of contentType is stored with.
+func Extension(contentType string) string {
+	return extensions[contentType]
+}
+
+// Image is an upload that is ready to store: the re-encoded original, with
+// any metadata stripped, a thumbnail no larger than ThumbnailSize and a
+// blurhash placeholder.
+type Image struct {
+	ContentType string
+	Data        []byte
+	Width       int
+	Height      int
+	Blurhash    string
+
+	ThumbnailContentType string
+	Thumbnail            []byte
+	ThumbnailWidth       int
+	ThumbnailHeight      int
+}
+
+// Process identifies an upload by its bytes, decodes it and re-encodes it
+// without metadata. JPEGs are turned upright according to their EXIF
+// orientation first, since the tag recording it is dropped. Animated GIFs
+// keep all of their frames; their thumbnail shows the first.
+func Process(data []byte) (Image, error) {
+	contentType := http.DetectContentType(data)
+	if _, ok := extensions[contentType]; !ok {
+		return Image{}, fmt.Errorf("%w: %s", ErrUnsupportedType, contentType)
+	}
+
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return Image{}, fmt.Errorf("decoding image: %w", err)
+	}
+	if int64(config.Width)*int64(config.Height) > MaxPixels {
+		return Image{}, ErrTooManyPixels
+	}
+	// The first frame of a GIF is drawn onto a canvas the size of its
+	// screen, which was checked above, but every frame is decoded too
+	if contentType == "image/gif" {
+		pixels, err := gifPixels(data)
+		if err != nil {
+			return Image{}, fmt.Errorf("decoding image: %w", err)
+		}
+		if pixels > MaxPixels {
+			return Image{}, ErrTooManyPixels
+		}
+	}
+
+	var out Image
+	var frame image.Image
+	switch contentType {
+	case "image/jpeg":
+		frame, err = jpeg.Decode(bytes.NewReader(data))
+		if err != nil {
+			return Image{}, fmt.Errorf("decoding image: %w", err)
+		}
+		frame = orient(frame, jpegOrientation(data))
+		out.Data, err = encodeJPEG(frame)
+	case "image/png":
+		frame, err = png.Decode(bytes.NewReader(data))
+		if err != nil {
+			return Image{}, fmt.Errorf("decoding image: %w", err)
+		}
+		out.Data, err = encodePNG(frame)
+	case "image/gif":
+		var anim *gif.GIF
+		anim, err = gif.DecodeAll(bytes.NewReader(data))
+		if err != nil {
+			return Image{}, fmt.Errorf("decoding image: %w", err)
+		}
+		frame = firstFrame(anim)
+		out.Data, err = encodeGIF(anim)
+	}
+	if err != nil {
+		return Image{}, fmt.Errorf("encoding image: %w", err)
+	}
+	out.ContentType = contentType
+	out.Width = frame.Bounds().Dx()
+	out.Height = frame.Bounds().Dy()
+
+	thumb := Resize(frame, ThumbnailSize)
+	out.Blurhash = Blurhash(thumb)
+	out.ThumbnailWidth = thumb.Bounds().Dx()
+	out.ThumbnailHeight = thumb.Bounds().Dy()
+	if contentType == "image/jpeg" {
+		out.ThumbnailContentType = "image/jpeg"
+		out.Thumbnail, err = encodeJPEG(thumb)
+	} else {
+		// PNG keeps the transparency GIFs and PNGs may have
+		out.ThumbnailContentType = "image/png"
+		out.Thumbnail, err = encodePNG(thumb)
+	}
+	if err != nil {
+		return Image{}, fmt.Errorf("encoding thumbnail: %w", err)
+	}
+	return out, nil
+}
+
+func encodeJPEG(img image.Image) ([]byte, error) {
+	var buf bytes.Buffer
+	err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: jpegQuality})
+	return buf.Bytes(), err
+}
+
+func encodePNG(img image.Image) ([]byte, error) {
+	var buf bytes.Buffer
+	err := png.Encode(&buf, img)
+	return buf.Bytes(), err
+}
+
+// encodeGIF writes the frames, timing and loop count of anim. Comments and
+// application extensions other than looping are not carried over.
+func encodeGIF(anim *gif.GIF) ([]byte, error) {
+	var buf bytes.Buffer
+	err := gif.EncodeAll(&buf, &gif.GIF{
+		Image:           anim.Image,
+		Delay:           anim.Delay,
+		LoopCount:       anim.LoopCount,
+		Disposal:        anim.Disposal,
+		Config:          anim.Config,
+		BackgroundIndex: anim.BackgroundIndex,
+	})
+	return buf.Bytes(), err
+}
+
+// firstFrame draws the first frame of anim onto a canvas the size of the
+// whole animation, since frames may cover only part of it.
+func firstFrame(anim *gif.GIF) image.Image {
+	canvas := image.NewRGBA(image.Rect(0, 0, anim.Config.Width, anim.Config.Height))
+	draw.Draw(canvas, anim.Image[0].Bounds(), anim.Image[0], anim.Image[0].Bounds().Min, draw.Over)
+	return canvas
+}
+
+// gifPixels adds up the area of every frame in a GIF without decoding them,
+// by walking the block structure of the file.
+func gifPixels(data []byte) (int64, error) {
+	errFormat := errors.New("gif: malformed file")
+	if len(data) < 13 {
+		return 0, errFormat
+	}
+	pos := 13
+	if flags := data[10]; flags&0x80 != 0 {
+		pos += 3 << (flags&0x07 + 1)
+	}
+	// skipSubBlocks moves past a chain of length-prefixed sub-blocks
+	skipSubBlocks := func() bool {
+		for pos < len(data) {
+			size := int(data[pos])
+			pos += 1 + size
+			if size == 0 {
+				return pos <= len(data)
+			}
+		}
+		return false
+	}
+
+	var pixels int64
+	for pos < len(data) {
+		switch data[pos] {
+		case 0x21: // extension
+			pos += 2
+			if !skipSubBlocks() {
+				return 0, errFormat
+			}
+		case 0x2C: // image descriptor
+			if pos+10 > len(data) {
+				return 0, errFormat
+			}
+			width := int64(data[pos+5]) | int64(data[pos+6])<<8
+			height := int64(data[pos+7]) | int64(data[pos+8])<<8
+			pixels += width * height
+			flags := data[pos+9]
+			pos += 10
+			if flags&0x80 != 0 {
+				pos += 3 << (flags&0x07 + 1)
+			}
+			pos++ // LZW minimum code size
+			if !skipSubBlocks() {
+				return 0, errFormat
+			}
+		case 0x3B: // trailer
+			return pixels, nil
+		default:
+			return 0, errFormat
+		}
+	}
+	return pixels, nil
+}
+
+// Resize scales img down, keeping its aspect ratio, so that neither side is
+// longer than size. Each output pixel averages the block of input pixels it
+// covers. Images that already fit are copied at their own size. The input is
+// read a row at a time, so only the output is held in full as well as img.
+func Resize(img image.Image, size int) *image.RGBA {
+	bounds := img.Bounds()
+	srcW, srcH := bounds.Dx(), bounds.Dy()
+	dstW, dstH := srcW, srcH
+	if srcW > size || srcH > size {
+		if srcW >= srcH {
+			dstW, dstH = size, max(1, srcH*size/srcW)
+		} else {
+			dstW, dstH = max(1, srcW*size/srcH), size
+		}
+	}
+	dst := image.NewRGBA(image.Rect(0, 0, dstW, dstH))
+	if dstW == srcW && dstH == srcH {
+		draw.Draw(dst, dst.Bounds(), img, bounds.Min, draw.Src)
+		return dst
+	}
+
+	row := image.NewRGBA(image.Rect(0, 0, srcW, 1))
+	sums := make([]int, dstW*4)
+	for y := 0; y < dstH; y++ {
+		y0, y1 := y*srcH/dstH, max((y+1)*srcH/dstH, y*srcH/dstH+1)
+		clear(sums)
+		for sy := y0; sy < y1; sy++ {
+			draw.Draw(row, row.Bounds(), img, image.Pt(bounds.Min.X, bounds.Min.Y+sy), draw.Src)
+			for x := 0; x < dstW; x++ {
+				x0, x1 := x*srcW/dstW, max((x+1)*srcW/dstW, x*srcW/dstW+1)
+				sum := sums[x*4 : x*4+4]
+				for i := x0 * 4; i < x1*4; i += 4 {
+					sum[0] += int(row.Pix[i])
+					sum[1] += int(row.Pix[i+1])
+					sum[2] += int(row.Pix[i+2])
+					sum[3] += int(row.Pix[i+3])
+				}
+			}
+		}
+		for x := 0; x < dstW; x++ {
+			x0, x1 := x*srcW/dstW, max((x+1)*srcW/dstW, x*srcW/dstW+1)
+			count := (y1 - y0) * (x1 - x0)
+			offset := y*dst.Stride + x*4
+			for c := 0; c < 4; c++ {
+				dst.Pix[offset+c] = uint8(sums[x*4+c] / count)
+			}
+		}
+	}
+	return dst
+}
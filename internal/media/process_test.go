@@ -0,0 +1,206 @@
+package media
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"testing"
+)
+
+// withOrientation inserts an EXIF segment holding orientation, and a marker
+// standing in for other metadata, after the start of a JPEG.
+func withOrientation(t *testing.T, jpg []byte, orientation uint16) []byte {
+	t.Helper()
+	tiff := []byte("MM\x00\x2a\x00\x00\x00\x08")
+	tiff = binary.BigEndian.AppendUint16(tiff, 1)
+	tiff = binary.BigEndian.AppendUint16(tiff, 0x0112)
+	tiff = binary.BigEndian.AppendUint16(tiff, 3)
+	tiff = binary.BigEndian.AppendUint32(tiff, 1)
+	tiff = binary.BigEndian.AppendUint16(tiff, orientation)
+	tiff = append(tiff, 0, 0, 0, 0, 0, 0)
+	tiff = append(tiff, "GPS 51.5N 0.12W"...)
+	segment := append([]byte("Exif\x00\x00"), tiff...)
+
+	out := []byte{0xFF, 0xD8, 0xFF, 0xE1}
+	out = binary.BigEndian.AppendUint16(out, uint16(len(segment)+2))
+	out = append(out, segment...)
+	return append(out, jpg[2:]...)
+}
+
+func encodeTestJPEG(t *testing.T, width, height int) []byte {
+	t.Helper()
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			img.Set(x, y, color.RGBA{uint8(x * 10), uint8(y * 10), 128, 255})
+		}
+	}
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, img, nil); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestProcessJPEGStripsMetadata(t *testing.T) {
+	tests := []struct {
+		orientation uint16
+		width       int
+		height      int
+	}{
+		{1, 40, 20},
+		{3, 40, 20},
+		{6, 20, 40},
+		{8, 20, 40},
+	}
+	for _, test := range tests {
+		data := withOrientation(t, encodeTestJPEG(t, 40, 20), test.orientation)
+		if got := jpegOrientation(data); got != int(test.orientation) {
+			t.Fatalf("orientation %d: read as %d", test.orientation, got)
+		}
+		result, err := Process(data)
+		if err != nil {
+			t.Fatalf("orientation %d: %v", test.orientation, err)
+		}
+		if result.ContentType != "image/jpeg" {
+			t.Errorf("orientation %d: have content type %q", test.orientation, result.ContentType)
+		}
+		if result.Width != test.width || result.Height != test.height {
+			t.Errorf("orientation %d: have %dx%d want %dx%d", test.orientation, result.Width, result.Height, test.width, test.height)
+		}
+		if bytes.Contains(result.Data, []byte("Exif")) || bytes.Contains(result.Data, []byte("GPS")) {
+			t.Errorf("orientation %d: metadata survived processing", test.orientation)
+		}
+	}
+}
+
+func TestProcessRejectsUnsupportedTypes(t *testing.T) {
+	inputs := map[string][]byte{
+		"text":          []byte("hello, world"),
+		"html":          []byte("<html><body>not an image</body></html>"),
+		"truncated png": []byte("\x89PNG\r\n\x1a\n\x00\x00"),
+	}
+	for name, data := range inputs {
+		_, err := Process(data)
+		if err == nil {
+			t.Errorf("%s: accepted", name)
+		}
+	}
+	_, err := Process([]byte("hello, world"))
+	if !errors.Is(err, ErrUnsupportedType) {
+		t.Errorf("text: have %v want ErrUnsupportedType", err)
+	}
+}
+
+func TestProcessRejectsHugeImages(t *testing.T) {
+	// A tiny PNG header claiming a 10000x10000 image
+	img := image.NewGray(image.Rect(0, 0, 1, 1))
+	var buf bytes.Buffer
+	png.Encode(&buf, img)
+	data := buf.Bytes()
+	binary.BigEndian.PutUint32(data[16:], 10000)
+	binary.BigEndian.PutUint32(data[20:], 10000)
+	binary.BigEndian.PutUint32(data[29:], crc32.ChecksumIEEE(data[12:29]))
+	_, err := Process(data)
+	if !errors.Is(err, ErrTooManyPixels) {
+		t.Fatalf("have %v want ErrTooManyPixels", err)
+	}
+}
+
+func TestProcessRejectsHugeGIFScreens(t *testing.T) {
+	// A 1x1 frame on a GIF screen claiming to be 20000x20000
+	img := image.NewPaletted(image.Rect(0, 0, 1, 1), color.Palette{color.Black, color.White})
+	var buf bytes.Buffer
+	if err := gif.Encode(&buf, img, nil); err != nil {
+		t.Fatal(err)
+	}
+	data := buf.Bytes()
+	binary.LittleEndian.PutUint16(data[6:], 20000)
+	binary.LittleEndian.PutUint16(data[8:], 20000)
+	pixels, err := gifPixels(data)
+	if err != nil || pixels != 1 {
+		t.Fatalf("gifPixels: have %d, %v want 1", pixels, err)
+	}
+	_, err = Process(data)
+	if !errors.Is(err, ErrTooManyPixels) {
+		t.Fatalf("have %v want ErrTooManyPixels", err)
+	}
+}
+
+func TestProcessGIFKeepsFrames(t *testing.T) {
+	palette := color.Palette{color.Black, color.White}
+	anim := &gif.GIF{LoopCount: 0}
+	for i := 0; i < 3; i++ {
+		anim.Image = append(anim.Image, image.NewPaletted(image.Rect(0, 0, 600, 300), palette))
+		anim.Delay = append(anim.Delay, 10)
+	}
+	var buf bytes.Buffer
+	if err := gif.EncodeAll(&buf, anim); err != nil {
+		t.Fatal(err)
+	}
+
+	pixels, err := gifPixels(buf.Bytes())
+	if err != nil || pixels != 3*600*300 {
+		t.Fatalf("gifPixels: have %d, %v want %d", pixels, err, 3*600*300)
+	}
+	result, err := Process(buf.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err := gif.DecodeAll(bytes.NewReader(result.Data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(out.Image) != 3 {
+		t.Errorf("have %d frames want 3", len(out.Image))
+	}
+	if result.ThumbnailContentType != "image/png" || result.ThumbnailWidth != 400 || result.ThumbnailHeight != 200 {
+		t.Errorf("have %s thumbnail %dx%d want image/png 400x200", result.ThumbnailContentType, result.ThumbnailWidth, result.ThumbnailHeight)
+	}
+}
+
+func TestResize(t *testing.T) {
+	tests := []struct {
+		width, height int
+		wantW, wantH  int
+	}{
+		{800, 400, 400, 200},
+		{400, 800, 200, 400},
+		{100, 50, 100, 50},
+		{4000, 1, 400, 1},
+	}
+	for _, test := range tests {
+		img := image.NewRGBA(image.Rect(0, 0, test.width, test.height))
+		result := Resize(img, 400)
+		if result.Bounds().Dx() != test.wantW || result.Bounds().Dy() != test.wantH {
+			t.Errorf("%dx%d: have %dx%d want %dx%d", test.width, test.height,
+				result.Bounds().Dx(), result.Bounds().Dy(), test.wantW, test.wantH)
+		}
+	}
+
+	// Averaging a black and white checkerboard gives grey
+	board := image.NewGray(image.Rect(0, 0, 2, 2))
+	board.SetGray(0, 0, color.Gray{255})
+	board.SetGray(1, 1, color.Gray{255})
+	grey := Resize(board, 1).RGBAAt(0, 0)
+	if grey.R != 127 || grey.A != 255 {
+		t.Errorf("have %v want grey", grey)
+	}
+
+	// Sub-images are read from their own bounds
+	halves := image.NewGray(image.Rect(0, 0, 4, 2))
+	for x := 2; x < 4; x++ {
+		halves.SetGray(x, 0, color.Gray{255})
+		halves.SetGray(x, 1, color.Gray{255})
+	}
+	white := Resize(halves.SubImage(image.Rect(2, 0, 4, 2)), 1).RGBAAt(0, 0)
+	if white.R != 255 || white.A != 255 {
+		t.Errorf("have %v want white", white)
+	}
+}
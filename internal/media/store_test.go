@@ -0,0 +1,43 @@
+package media
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+)
+
+func TestLocalStore(t *testing.T) {
+	store, err := NewLocalStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	if err := store.Put(ctx, "a.jpg", "image/jpeg", []byte("data")); err != nil {
+		t.Fatal(err)
+	}
+	body, err := store.Get(ctx, "a.jpg")
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, _ := io.ReadAll(body)
+	body.Close()
+	if string(data) != "data" {
+		t.Errorf("have '%s' want 'data'", data)
+	}
+	if err := store.Delete(ctx, "a.jpg"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.Get(ctx, "a.jpg"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("have %v want ErrNotFound", err)
+	}
+	if err := store.Delete(ctx, "a.jpg"); err != nil {
+		t.Errorf("deleting a missing file: %v", err)
+	}
+
+	for _, key := range []string{"", "..", "../a.jpg", "dir/a.jpg"} {
+		if err := store.Put(ctx, key, "image/jpeg", []byte("data")); err == nil {
+			t.Errorf("key '%s' accepted", key)
+		}
+	}
+}
@@ -0,0 +1,250 @@
+package moderation
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+	"github.com/jthughes/chirpynetwork/internal/textlen"
+)
+
+// Kind is the check a rule performs.
+type Kind string
+
+const (
+	KindBannedWords Kind = "banned_words"
+	KindRegex       Kind = "regex"
+	KindMaxLinks    Kind = "max_links"
+	KindMaxMentions Kind = "max_mentions"
+)
+
+// Action is what happens to a chirp that breaks a rule.
+type Action string
+
+const (
+	ActionReject Action = "reject"
+	ActionMask   Action = "mask"
+	ActionFlag   Action = "flag"
+)
+
+// Config holds the settings of a rule. Which fields apply depends on its
+// Kind: Words for banned_words, Pattern for regex and Max for max_links and
+// max_mentions.
+type Config struct {
+	Words   []string `json:"words,omitempty"`
+	Pattern string   `json:"pattern,omitempty"`
+	Max     int      `json:"max,omitempty"`
+}
+
+type Rule struct {
+	ID     uuid.UUID
+	Name   string
+	Kind   Kind
+	Action Action
+	Config Config
+}
+
+var mentionPattern = regexp.MustCompile(`(?:^|[^\w@])(@\w+)`)
+
+const mask = "****"
+
+// compiledRule is a Rule with its matcher built.
+type compiledRule struct {
+	Rule
+	pattern *regexp.Regexp
+}
+
+// Violation records a rule that a chirp broke.
+type Violation struct {
+	RuleID   uuid.UUID `json:"rule_id"`
+	RuleName string    `json:"rule_name"`
+	Action   Action    `json:"action"`
+	Reason   string    `json:"reason"`
+}
+
+// Result is the outcome of running a chirp body through a Pipeline. Body has
+// any masking applied. If Rejected is set, the chirp must not be posted.
+type Result struct {
+	Body     string
+	Rejected *Violation
+	Flags    []Violation
+}
+
+// Pipeline is an ordered chain of rules.
+type Pipeline struct {
+	rules []compiledRule
+}
+
+// Validate checks that a rule is well formed without building a pipeline.
+func (rule Rule) Validate() error {
+	_, err := compile(rule)
+	return err
+}
+
+func compile(rule Rule) (compiledRule, error) {
+	switch rule.Action {
+	case ActionReject, ActionMask, ActionFlag:
+	default:
+		return compiledRule{}, fmt.Errorf("unknown action %q", rule.Action)
+	}
+
+	compiled := compiledRule{Rule: rule}
+	switch rule.Kind {
+	case KindBannedWords:
+		if len(rule.Config.Words) == 0 {
+			return compiledRule{}, fmt.Errorf("banned_words rules need at least one word")
+		}
+		words := []string{}
+		for _, word := range rule.Config.Words {
+			word = strings.TrimSpace(word)
+			if word == "" {
+				return compiledRule{}, fmt.Errorf("banned words must not be blank")
+			}
+			words = append(words, regexp.QuoteMeta(word))
+		}
+		// RE2's \b only knows ASCII word characters, so the boundaries are
+		// spelled out; the one before a word is checked in findWords
+		compiled.pattern = regexp.MustCompile(`(?i)(` + strings.Join(words, "|") + `)(?:$|[^\p{L}\p{M}\p{N}_])`)
+	case KindRegex:
+		if rule.Config.Pattern == "" {
+			return compiledRule{}, fmt.Errorf("regex rules need a pattern")
+		}
+		pattern, err := regexp.Compile(rule.Config.Pattern)
+		if err != nil {
+			return compiledRule{}, fmt.Errorf("invalid pattern: %v", err)
+		}
+		compiled.pattern = pattern
+	case KindMaxLinks:
+		compiled.pattern = textlen.URLPattern
+	case KindMaxMentions:
+		compiled.pattern = mentionPattern
+	default:
+		return compiledRule{}, fmt.Errorf("unknown kind %q", rule.Kind)
+	}
+
+	if (rule.Kind == KindMaxLinks || rule.Kind == KindMaxMentions) && rule.Config.Max < 0 {
+		return compiledRule{}, fmt.Errorf("max must not be negative")
+	}
+	return compiled, nil
+}
+
+// NewPipeline compiles rules into a pipeline that runs them in the given
+// order.
+func NewPipeline(rules []Rule) (Pipeline, error) {
+	pipeline := Pipeline{}
+	for _, rule := range rules {
+		compiled, err := compile(rule)
+		if err != nil {
+			return Pipeline{}, fmt.Errorf("rule %q: %w", rule.Name, err)
+		}
+		pipeline.rules = append(pipeline.rules, compiled)
+	}
+	return pipeline, nil
+}
+
+// Apply runs body through every rule in order. Masking rules rewrite the body
+// seen by later rules. The first rejecting rule to fire stops the pipeline.
+func (p Pipeline) Apply(body string) Result {
+	result := Result{Body: body, Flags: []Violation{}}
+	for _, rule := range p.rules {
+		matches, allowed, reason := rule.check(result.Body)
+		if matches == nil {
+			continue
+		}
+		violation := Violation{
+			RuleID:   rule.ID,
+			RuleName: rule.Name,
+			Action:   rule.Action,
+			Reason:   reason,
+		}
+		switch rule.Action {
+		case ActionReject:
+			result.Rejected = &violation
+			return result
+		case ActionMask:
+			result.Body = maskMatches(result.Body, matches[allowed:])
+		case ActionFlag:
+			result.Flags = append(result.Flags, violation)
+		}
+	}
+	return result
+}
+
+// check returns the matches of rule in body if it breaks the rule, along with
+// how many of them are allowed, and a reason for the violation.
+func (rule compiledRule) check(body string) ([][]int, int, string) {
+	switch rule.Kind {
+	case KindMaxLinks, KindMaxMentions:
+		matches := rule.pattern.FindAllStringSubmatchIndex(body, -1)
+		if len(matches) <= rule.Config.Max {
+			return nil, 0, ""
+		}
+		// Mentions match a leading character too, so only keep the mention
+		for i, match := range matches {
+			if len(match) >= 4 {
+				matches[i] = match[2:4]
+			}
+		}
+		noun := "links"
+		if rule.Kind == KindMaxMentions {
+			noun = "mentions"
+		}
+		return matches, rule.Config.Max, fmt.Sprintf("chirp has %d %s, at most %d allowed", len(matches), noun, rule.Config.Max)
+	case KindBannedWords:
+		matches := findWords(rule.pattern, body)
+		if len(matches) == 0 {
+			return nil, 0, ""
+		}
+		return matches, 0, "chirp contains a banned word"
+	default:
+		matches := rule.pattern.FindAllStringIndex(body, -1)
+		if len(matches) == 0 {
+			return nil, 0, ""
+		}
+		return matches, 0, "chirp matches a blocked pattern"
+	}
+}
+
+// findWords returns where the banned words pattern matches whole words in
+// body. The pattern checks what follows a word, consuming it, so the search
+// resumes right after each word for the next one to see what precedes it.
+func findWords(pattern *regexp.Regexp, body string) [][]int {
+	matches := [][]int{}
+	pos := 0
+	for pos < len(body) {
+		match := pattern.FindStringSubmatchIndex(body[pos:])
+		if match == nil {
+			break
+		}
+		start, end := pos+match[2], pos+match[3]
+		before, _ := utf8.DecodeLastRuneInString(body[:start])
+		if start > 0 && isWordRune(before) {
+			// Part of a longer word, so try again from its next character
+			_, size := utf8.DecodeRuneInString(body[start:])
+			pos = start + size
+			continue
+		}
+		matches = append(matches, []int{start, end})
+		pos = end
+	}
+	return matches
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsMark(r) || unicode.IsNumber(r) || r == '_'
+}
+
+func maskMatches(body string, matches [][]int) string {
+	out := strings.Builder{}
+	last := 0
+	for _, match := range matches {
+		out.WriteString(body[last:match[0]])
+		out.WriteString(mask)
+		last = match[1]
+	}
+	out.WriteString(body[last:])
+	return out.String()
+}
@@ -0,0 +1,110 @@
+package moderation
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestMaskBannedWords(t *testing.T) {
+	pipeline, err := NewPipeline([]Rule{{
+		ID:     uuid.New(),
+		Name:   "profanity",
+		Kind:   KindBannedWords,
+		Action: ActionMask,
+		Config: Config{Words: []string{"kerfuffle", "sharbert"}},
+	}})
+	if err != nil {
+		t.Fatalf("Failed to build pipeline: %v", err)
+	}
+	result := pipeline.Apply("What a Kerfuffle, sharbert! kerfuffles stay")
+	expected := "What a ****, ****! kerfuffles stay"
+	if result.Body != expected {
+		t.Fatalf("Body mismatch: have '%s' want '%s'", result.Body, expected)
+	}
+	if result.Rejected != nil || len(result.Flags) != 0 {
+		t.Fatalf("Unexpected violations: %+v", result)
+	}
+}
+
+func TestMaskNonASCIIBannedWords(t *testing.T) {
+	pipeline, err := NewPipeline([]Rule{{
+		ID:     uuid.New(),
+		Name:   "profanity",
+		Kind:   KindBannedWords,
+		Action: ActionMask,
+		Config: Config{Words: []string{"café", "ñoño", "плохо", "坏蛋", "c++"}},
+	}})
+	if err != nil {
+		t.Fatalf("Failed to build pipeline: %v", err)
+	}
+	tests := []struct {
+		body     string
+		expected string
+	}{
+		{"Un CAFÉ, s'il vous plaît", "Un ****, s'il vous plaît"},
+		{"cafés stay", "cafés stay"},
+		{"¡ñoño!", "¡****!"},
+		{"это плохо", "это ****"},
+		{"очень плохое", "очень плохое"},
+		{"坏蛋", "****"},
+		{"c++ c++, not c++11", "**** ****, not c++11"},
+		{"xc++ stays", "xc++ stays"},
+	}
+	for _, test := range tests {
+		result := pipeline.Apply(test.body)
+		if result.Body != test.expected {
+			t.Errorf("Body mismatch: have '%s' want '%s'", result.Body, test.expected)
+		}
+	}
+}
+
+func TestRejectStopsPipeline(t *testing.T) {
+	rejectID := uuid.New()
+	pipeline, err := NewPipeline([]Rule{
+		{ID: rejectID, Name: "no spam", Kind: KindRegex, Action: ActionReject, Config: Config{Pattern: `(?i)buy now`}},
+		{ID: uuid.New(), Name: "review links", Kind: KindMaxLinks, Action: ActionFlag, Config: Config{Max: 0}},
+	})
+	if err != nil {
+		t.Fatalf("Failed to build pipeline: %v", err)
+	}
+	result := pipeline.Apply("BUY NOW at https://example.com")
+	if result.Rejected == nil || result.Rejected.RuleID != rejectID {
+		t.Fatalf("Expected rejection by '%s', got %+v", rejectID, result.Rejected)
+	}
+	if len(result.Flags) != 0 {
+		t.Fatalf("Rules after a rejection should not run: %+v", result.Flags)
+	}
+}
+
+func TestFlagAndMaskCounts(t *testing.T) {
+	pipeline, err := NewPipeline([]Rule{
+		{ID: uuid.New(), Name: "mentions", Kind: KindMaxMentions, Action: ActionFlag, Config: Config{Max: 1}},
+		{ID: uuid.New(), Name: "links", Kind: KindMaxLinks, Action: ActionMask, Config: Config{Max: 1}},
+	})
+	if err != nil {
+		t.Fatalf("Failed to build pipeline: %v", err)
+	}
+	result := pipeline.Apply("@alice @bob see https://a.example and http://b.example, mail me@host")
+	if len(result.Flags) != 1 || result.Flags[0].RuleName != "mentions" {
+		t.Fatalf("Expected a mentions flag, got %+v", result.Flags)
+	}
+	expected := "@alice @bob see https://a.example and ****, mail me@host"
+	if result.Body != expected {
+		t.Fatalf("Body mismatch: have '%s' want '%s'", result.Body, expected)
+	}
+}
+
+func TestInvalidRules(t *testing.T) {
+	for _, rule := range []Rule{
+		{Name: "bad kind", Kind: "shouting", Action: ActionReject},
+		{Name: "bad action", Kind: KindMaxLinks, Action: "delete"},
+		{Name: "no words", Kind: KindBannedWords, Action: ActionMask},
+		{Name: "bad regex", Kind: KindRegex, Action: ActionReject, Config: Config{Pattern: "("}},
+		{Name: "negative max", Kind: KindMaxMentions, Action: ActionFlag, Config: Config{Max: -1}},
+	} {
+		if rule.Validate() == nil {
+			t.Errorf("Rule '%s' validated when it should have failed", rule.Name)
+		}
+	}
+}
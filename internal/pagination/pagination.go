@@ -0,0 +1,154 @@
+package pagination
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	DefaultLimit = 20
+	MaxLimit     = 100
+)
+
+// Cursor marks a position in a list ordered by (created_at, id). Clients only
+// ever see it in its encoded form and should treat it as opaque.
+type Cursor struct {
+	CreatedAt time.Time
+	ID        uuid.UUID
+}
+
+func (c Cursor) Encode() string {
+	raw := fmt.Sprintf("%d:%s", c.CreatedAt.UnixMicro(), c.ID.String())
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+func DecodeCursor(s string) (Cursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return Cursor{}, fmt.Errorf("invalid cursor")
+	}
+	micros, id, found := strings.Cut(string(raw), ":")
+	if !found {
+		return Cursor{}, fmt.Errorf("invalid cursor")
+	}
+	usec, err := strconv.ParseInt(micros, 10, 64)
+	if err != nil {
+		return Cursor{}, fmt.Errorf("invalid cursor")
+	}
+	cursorID, err := uuid.Parse(id)
+	if err != nil {
+		return Cursor{}, fmt.Errorf("invalid cursor")
+	}
+	return Cursor{
+		CreatedAt: time.UnixMicro(usec).UTC(),
+		ID:        cursorID,
+	}, nil
+}
+
+// Request holds the paging parameters of a list request.
+type Request struct {
+	Limit     int
+	Cursor    Cursor
+	HasCursor bool
+}
+
+// ParseRequest reads the limit and cursor query parameters, applying
+// DefaultLimit when no limit is given.
+func ParseRequest(query url.Values) (Request, error) {
+	req := Request{Limit: DefaultLimit}
+	if limit := query.Get("limit"); limit != "" {
+		n, err := strconv.Atoi(limit)
+		if err != nil || n < 1 || n > MaxLimit {
+			return Request{}, fmt.Errorf("limit must be between 1 and %d", MaxLimit)
+		}
+		req.Limit = n
+	}
+	if cursor := query.Get("cursor"); cursor != "" {
+		c, err := DecodeCursor(cursor)
+		if err != nil {
+			return Request{}, err
+		}
+		req.Cursor = c
+		req.HasCursor = true
+	}
+	return req, nil
+}
+
+// OffsetRequest holds the paging parameters of a list that can't be ordered
+// by (created_at, id), such as relevance-ranked search results. Its cursor
+// encodes a row offset.
+type OffsetRequest struct {
+	Limit  int
+	Offset int
+}
+
+func EncodeOffset(offset int) string {
+	return base64.RawURLEncoding.EncodeToString([]byte("offset:" + strconv.Itoa(offset)))
+}
+
+func DecodeOffset(s string) (int, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid cursor")
+	}
+	value, found := strings.CutPrefix(string(raw), "offset:")
+	if !found {
+		return 0, fmt.Errorf("invalid cursor")
+	}
+	offset, err := strconv.Atoi(value)
+	if err != nil || offset < 0 {
+		return 0, fmt.Errorf("invalid cursor")
+	}
+	return offset, nil
+}
+
+// ParseOffsetRequest reads the limit and cursor query parameters of an
+// offset-paged list.
+func ParseOffsetRequest(query url.Values) (OffsetRequest, error) {
+	req := OffsetRequest{Limit: DefaultLimit}
+	if limit := query.Get("limit"); limit != "" {
+		n, err := strconv.Atoi(limit)
+		if err != nil || n < 1 || n > MaxLimit {
+			return OffsetRequest{}, fmt.Errorf("limit must be between 1 and %d", MaxLimit)
+		}
+		req.Limit = n
+	}
+	if cursor := query.Get("cursor"); cursor != "" {
+		offset, err := DecodeOffset(cursor)
+		if err != nil {
+			return OffsetRequest{}, err
+		}
+		req.Offset = offset
+	}
+	return req, nil
+}
+
+// NextURL returns u with its cursor query parameter replaced by the encoded
+// cursor next.
+func NextURL(u *url.URL, next string) string {
+	query := u.Query()
+	query.Set("cursor", next)
+	nextURL := url.URL{
+		Path:     u.Path,
+		RawQuery: query.Encode(),
+	}
+	return nextURL.String()
+}
+
+// Trim cuts a page that was fetched with one row more than limit back down to
+// size. If the extra row was present, it returns the cursor of the last row
+// kept so the caller can advertise the next page.
+func Trim[T any](items []T, limit int, cursorOf func(T) Cursor) ([]T, *Cursor) {
+	if len(items) <= limit {
+		return items, nil
+	}
+	items = items[:limit]
+	next := cursorOf(items[limit-1])
+	return items, &next
+}
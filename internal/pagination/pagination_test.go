@@ -0,0 +1,118 @@
+package pagination
+
+import (
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestCursorRoundTrip(t *testing.T) {
+	cursor := Cursor{
+		CreatedAt: time.Date(2025, 1, 2, 3, 4, 5, 123456000, time.UTC),
+		ID:        uuid.New(),
+	}
+	result, err := DecodeCursor(cursor.Encode())
+	if err != nil {
+		t.Fatalf("Failed to decode cursor: %v", err)
+	}
+	if !result.CreatedAt.Equal(cursor.CreatedAt) || result.ID != cursor.ID {
+		t.Fatalf("Cursor mismatch: have '%v' want '%v'", result, cursor)
+	}
+}
+
+func TestInvalidCursor(t *testing.T) {
+	_, err := DecodeCursor("not-a-cursor")
+	if err == nil {
+		t.Fatal("Decoded an invalid cursor")
+	}
+}
+
+func TestParseRequest(t *testing.T) {
+	req, err := ParseRequest(url.Values{})
+	if err != nil {
+		t.Fatalf("Failed to parse empty request: %v", err)
+	}
+	if req.Limit != DefaultLimit || req.HasCursor {
+		t.Fatalf("Unexpected defaults: %+v", req)
+	}
+
+	_, err = ParseRequest(url.Values{"limit": {"0"}})
+	if err == nil {
+		t.Fatal("Accepted a limit of 0")
+	}
+	_, err = ParseRequest(url.Values{"limit": {"1000"}})
+	if err == nil {
+		t.Fatal("Accepted a limit above the maximum")
+	}
+
+	cursor := Cursor{CreatedAt: time.Now().UTC().Truncate(time.Microsecond), ID: uuid.New()}
+	req, err = ParseRequest(url.Values{"limit": {"5"}, "cursor": {cursor.Encode()}})
+	if err != nil {
+		t.Fatalf("Failed to parse request: %v", err)
+	}
+	if req.Limit != 5 || !req.HasCursor || req.Cursor.ID != cursor.ID {
+		t.Fatalf("Unexpected request: %+v", req)
+	}
+}
+
+func TestNextURL(t *testing.T) {
+	u, _ := url.Parse("/api/chirps?author_id=abc&cursor=old&sort=desc")
+	cursor := Cursor{CreatedAt: time.Now().UTC(), ID: uuid.New()}
+	result, err := url.Parse(NextURL(u, cursor.Encode()))
+	if err != nil {
+		t.Fatalf("Failed to parse next url: %v", err)
+	}
+	if result.Path != "/api/chirps" {
+		t.Fatalf("Path mismatch: have '%s'", result.Path)
+	}
+	query := result.Query()
+	if query.Get("cursor") != cursor.Encode() || query.Get("sort") != "desc" || query.Get("author_id") != "abc" {
+		t.Fatalf("Unexpected query: %v", query)
+	}
+}
+
+func TestTrim(t *testing.T) {
+	ids := []uuid.UUID{uuid.New(), uuid.New(), uuid.New()}
+	cursorOf := func(id uuid.UUID) Cursor { return Cursor{ID: id} }
+
+	items, next := Trim(ids, 3, cursorOf)
+	if len(items) != 3 || next != nil {
+		t.Fatalf("Trimmed a short page: %d items, next %v", len(items), next)
+	}
+
+	items, next = Trim(ids, 2, cursorOf)
+	if len(items) != 2 || next == nil {
+		t.Fatalf("Failed to trim page: %d items, next %v", len(items), next)
+	}
+	if next.ID != ids[1] {
+		t.Fatalf("Next cursor mismatch: have '%s' want '%s'", next.ID, ids[1])
+	}
+}
+
+func TestOffsetRoundTrip(t *testing.T) {
+	result, err := DecodeOffset(EncodeOffset(40))
+	if err != nil {
+		t.Fatalf("Failed to decode offset: %v", err)
+	}
+	if result != 40 {
+		t.Fatalf("Offset mismatch: have '%d' want '40'", result)
+	}
+
+	cursor := Cursor{CreatedAt: time.Now().UTC(), ID: uuid.New()}
+	_, err = DecodeOffset(cursor.Encode())
+	if err == nil {
+		t.Fatal("Decoded a keyset cursor as an offset")
+	}
+}
+
+func TestParseOffsetRequest(t *testing.T) {
+	req, err := ParseOffsetRequest(url.Values{"limit": {"10"}, "cursor": {EncodeOffset(20)}})
+	if err != nil {
+		t.Fatalf("Failed to parse request: %v", err)
+	}
+	if req.Limit != 10 || req.Offset != 20 {
+		t.Fatalf("Unexpected request: %+v", req)
+	}
+}
@@ -0,0 +1,95 @@
+package search
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Query is a parsed chirp search. Text keeps the free-text part of the search,
+// including quoted phrases, "-word" exclusions and OR, in the syntax accepted
+// by Postgres' websearch_to_tsquery.
+type Query struct {
+	Text  string
+	From  *uuid.UUID
+	Since *time.Time
+	Until *time.Time
+}
+
+const dateLayout = "2006-01-02"
+
+// Parse splits a search into its free text and its operators:
+//
+//	from:<user id>   only chirps by that user
+//	since:<date>     only chirps posted on or after the date (YYYY-MM-DD)
+//	until:<date>     only chirps posted on or before the date (YYYY-MM-DD)
+//
+// Operators inside quoted phrases are treated as text.
+func Parse(raw string) (Query, error) {
+	query := Query{}
+	text := []string{}
+	for _, token := range tokenize(raw) {
+		key, value, found := strings.Cut(token, ":")
+		if !found || strings.HasPrefix(token, "\"") {
+			text = append(text, token)
+			continue
+		}
+		switch strings.ToLower(key) {
+		case "from":
+			id, err := uuid.Parse(value)
+			if err != nil {
+				return Query{}, fmt.Errorf("invalid from: user id %q", value)
+			}
+			query.From = &id
+		case "since":
+			since, err := time.Parse(dateLayout, value)
+			if err != nil {
+				return Query{}, fmt.Errorf("invalid since: date %q", value)
+			}
+			query.Since = &since
+		case "until":
+			until, err := time.Parse(dateLayout, value)
+			if err != nil {
+				return Query{}, fmt.Errorf("invalid until: date %q", value)
+			}
+			// until is inclusive, so search up to the start of the next day
+			until = until.AddDate(0, 0, 1)
+			query.Until = &until
+		default:
+			text = append(text, token)
+		}
+	}
+	if query.Since != nil && query.Until != nil && !query.Since.Before(*query.Until) {
+		return Query{}, fmt.Errorf("since: must not be after until:")
+	}
+	query.Text = strings.Join(text, " ")
+	return query, nil
+}
+
+// tokenize splits on whitespace, keeping quoted phrases (and a leading '-'
+// that excludes them) together as a single token.
+func tokenize(raw string) []string {
+	tokens := []string{}
+	current := strings.Builder{}
+	quoted := false
+	for _, r := range raw {
+		switch {
+		case r == '"':
+			quoted = !quoted
+			current.WriteRune(r)
+		case !quoted && (r == ' ' || r == '\t' || r == '\n'):
+			if current.Len() > 0 {
+				tokens = append(tokens, current.String())
+				current.Reset()
+			}
+		default:
+			current.WriteRune(r)
+		}
+	}
+	if current.Len() > 0 {
+		tokens = append(tokens, current.String())
+	}
+	return tokens
+}
@@ -0,0 +1,68 @@
+package search
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestParseText(t *testing.T) {
+	query, err := Parse(`golang "http servers"   -java`)
+	if err != nil {
+		t.Fatalf("Failed to parse query: %v", err)
+	}
+	expected := `golang "http servers" -java`
+	if query.Text != expected {
+		t.Fatalf("Text mismatch: have '%s' want '%s'", query.Text, expected)
+	}
+	if query.From != nil || query.Since != nil || query.Until != nil {
+		t.Fatalf("Unexpected operators: %+v", query)
+	}
+}
+
+func TestParseOperators(t *testing.T) {
+	userID := uuid.New()
+	query, err := Parse("chirps from:" + userID.String() + " since:2025-01-01 until:2025-01-31")
+	if err != nil {
+		t.Fatalf("Failed to parse query: %v", err)
+	}
+	if query.Text != "chirps" {
+		t.Fatalf("Text mismatch: have '%s' want 'chirps'", query.Text)
+	}
+	if query.From == nil || *query.From != userID {
+		t.Fatalf("From mismatch: have '%v' want '%s'", query.From, userID)
+	}
+	since := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	if query.Since == nil || !query.Since.Equal(since) {
+		t.Fatalf("Since mismatch: have '%v' want '%v'", query.Since, since)
+	}
+	until := time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC)
+	if query.Until == nil || !query.Until.Equal(until) {
+		t.Fatalf("Until mismatch: have '%v' want '%v'", query.Until, until)
+	}
+}
+
+func TestParseOperatorInPhrase(t *testing.T) {
+	query, err := Parse(`"since:yesterday" news`)
+	if err != nil {
+		t.Fatalf("Failed to parse query: %v", err)
+	}
+	if query.Since != nil || query.Text != `"since:yesterday" news` {
+		t.Fatalf("Operator inside phrase was parsed: %+v", query)
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	for _, raw := range []string{
+		"from:nobody",
+		"since:01/01/2025",
+		"until:tomorrow",
+		"since:2025-02-01 until:2025-01-01",
+	} {
+		_, err := Parse(raw)
+		if err == nil {
+			t.Errorf("Parse(%q) succeeded when it should have failed", raw)
+		}
+	}
+}
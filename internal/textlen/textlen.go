@@ -0,0 +1,37 @@
+// Package textlen measures chirp length the way people count characters:
+// in user-perceived characters (grapheme clusters) rather than bytes, with
+// links weighted to a fixed length.
+package textlen
+
+import (
+	"regexp"
+
+	"github.com/rivo/uniseg"
+)
+
+// URLWeight is how many characters a link counts for, however long it is.
+const URLWeight = 23
+
+// URLPattern matches the links in a chirp: anything starting with http://,
+// https:// or www., up to whitespace and without trailing punctuation, as in
+// "see www.example.com." Everything that treats links specially uses it, so
+// they all agree on what a link is.
+var URLPattern = regexp.MustCompile(`(?i)\b(?:https?://|www\.)\S*[^\s.,;:!?)\]'"]`)
+
+// Count returns the weighted length of s: every link counts as URLWeight and
+// everything else counts one per grapheme cluster.
+func Count(s string) int {
+	count := 0
+	last := 0
+	for _, match := range URLPattern.FindAllStringIndex(s, -1) {
+		count += Graphemes(s[last:match[0]]) + URLWeight
+		last = match[1]
+	}
+	return count + Graphemes(s[last:])
+}
+
+// Graphemes counts the extended grapheme clusters in s, as Unicode defines
+// them in UAX #29, which is what clients count as characters.
+func Graphemes(s string) int {
+	return uniseg.GraphemeClusterCount(s)
+}
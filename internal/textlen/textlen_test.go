@@ -0,0 +1,60 @@
+package textlen
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestGraphemes(t *testing.T) {
+	tests := []struct {
+		name string
+		s    string
+		want int
+	}{
+		{"empty", "", 0},
+		{"ascii", "hello", 5},
+		{"accented", "café", 4},
+		{"combining accent", "cafe\u0301", 4},
+		{"crlf", "a\r\nb", 3},
+		{"emoji", "😀😀😀", 3},
+		{"skin tone", "👍\U0001f3fd", 1},
+		{"variation selector", "❤\ufe0f", 1},
+		{"zwj family", "👨\u200d👩\u200d👧\u200d👦", 1},
+		{"zwj after text", "a\u200d😀", 2},
+		{"flags", "🇳🇿🇦🇺", 2},
+		{"odd regional indicators", "🇳🇿🇦", 2},
+		{"hangul syllables", "한국어", 3},
+		{"hangul jamo", "\u1112\u1161\u11ab", 1},
+		{"cjk", "日本語", 3},
+		{"prepended mark", "\u0600\u0661", 1},
+		{"emoji keycap", "1\ufe0f\u20e3", 1},
+	}
+	for _, test := range tests {
+		result := Graphemes(test.s)
+		if result != test.want {
+			t.Errorf("%s: Graphemes(%q) = %d, want %d", test.name, test.s, result, test.want)
+		}
+	}
+}
+
+func TestCountWeightsLinks(t *testing.T) {
+	short := "see https://example.com"
+	if result := Count(short); result != 4+URLWeight {
+		t.Fatalf("Count(%q) = %d, want %d", short, result, 4+URLWeight)
+	}
+
+	long := "see https://example.com/" + strings.Repeat("a", 200) + "."
+	if result := Count(long); result != 4+URLWeight+1 {
+		t.Fatalf("Count of long link = %d, want %d", result, 4+URLWeight+1)
+	}
+}
+
+func TestCountEmoji(t *testing.T) {
+	body := strings.Repeat("🎉", 50)
+	if len(body) <= 140 {
+		t.Fatal("Test body should be over 140 bytes")
+	}
+	if result := Count(body); result != 50 {
+		t.Fatalf("Count of 50 emoji = %d, want 50", result)
+	}
+}
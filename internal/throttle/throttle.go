@@ -0,0 +1,85 @@
+// Package throttle decides how long login attempts are held off after they
+// fail. It only does the arithmetic; callers keep the State of each account
+// and address somewhere all instances can see it.
+package throttle
+
+import "time"
+
+// Policy is how failures against one account or from one address are
+// punished. After FreeFailures failures, each further failure holds off the
+// next attempt for BaseDelay, doubling every time up to MaxDelay. Reaching
+// LockoutFailures locks attempts out for LockoutDuration. Failures are
+// forgotten once none has happened for Window.
+type Policy struct {
+	FreeFailures    int
+	BaseDelay       time.Duration
+	MaxDelay        time.Duration
+	LockoutFailures int
+	LockoutDuration time.Duration
+	Window          time.Duration
+}
+
+// State is the record of recent failures for one account or address.
+type State struct {
+	Failures      int
+	LastFailureAt time.Time
+	LockedUntil   time.Time
+}
+
+// RetryAfter is how long until another attempt is allowed, or zero if one is
+// allowed now.
+func (s State) RetryAfter(now time.Time) time.Duration {
+	if !s.LockedUntil.After(now) {
+		return 0
+	}
+	return s.LockedUntil.Sub(now)
+}
+
+// Fail records a failed attempt at now. It reports whether the failure
+// started a lockout, as opposed to a backoff delay, so the owner of the
+// account can be told.
+func (p Policy) Fail(s State, now time.Time) (State, bool) {
+	if now.Sub(s.LastFailureAt) >= p.Window && !s.LockedUntil.After(now) {
+		s = State{}
+	}
+	s.Failures++
+	s.LastFailureAt = now
+	s.LockedUntil = p.lockedUntil(s.Failures, now)
+	return s, s.Failures == p.LockoutFailures
+}
+
+// Undo takes back a failure Fail recorded for an attempt that turned out to
+// succeed, as when failures are counted before the attempt is checked. The
+// hold-off is worked out again from the failures left.
+func (p Policy) Undo(s State) State {
+	if s.Failures == 0 {
+		return s
+	}
+	s.Failures--
+	s.LockedUntil = p.lockedUntil(s.Failures, s.LastFailureAt)
+	return s
+}
+
+// lockedUntil is when attempts may start again after n failures, the last of
+// them at last, or zero if they need not wait.
+func (p Policy) lockedUntil(n int, last time.Time) time.Time {
+	if n >= p.LockoutFailures {
+		return last.Add(p.LockoutDuration)
+	}
+	if n > p.FreeFailures {
+		return last.Add(p.delay(n - p.FreeFailures))
+	}
+	return time.Time{}
+}
+
+// delay is the backoff after the nth failure past the free ones.
+func (p Policy) delay(n int) time.Duration {
+	delay := p.BaseDelay
+	for i := 1; i < n; i++ {
+		delay *= 2
+		if delay >= p.MaxDelay {
+			return p.MaxDelay
+		}
+	}
+	return min(delay, p.MaxDelay)
+}
@@ -0,0 +1,136 @@
+package throttle
+
+import (
+	"testing"
+	"time"
+)
+
+var testPolicy = Policy{
+	FreeFailures:    3,
+	BaseDelay:       time.Second,
+	MaxDelay:        10 * time.Second,
+	LockoutFailures: 8,
+	LockoutDuration: time.Hour,
+	Window:          15 * time.Minute,
+}
+
+func TestFailBackoff(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		failures   int
+		retryAfter time.Duration
+		locked     bool
+	}{
+		{1, 0, false},
+		{2, 0, false},
+		{3, 0, false},
+		{4, time.Second, false},
+		{5, 2 * time.Second, false},
+		{6, 4 * time.Second, false},
+		{7, 8 * time.Second, false},
+		{8, time.Hour, true},
+		{9, time.Hour, false},
+	}
+
+	state := State{}
+	for _, test := range tests {
+		var locked bool
+		state, locked = testPolicy.Fail(state, now)
+		if state.Failures != test.failures {
+			t.Errorf("failures: have %d want %d", state.Failures, test.failures)
+		}
+		if have := state.RetryAfter(now); have != test.retryAfter {
+			t.Errorf("failure %d: have retry after %s want %s", test.failures, have, test.retryAfter)
+		}
+		if locked != test.locked {
+			t.Errorf("failure %d: have locked %v want %v", test.failures, locked, test.locked)
+		}
+	}
+}
+
+func TestMaxDelay(t *testing.T) {
+	policy := testPolicy
+	policy.LockoutFailures = 100
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	state := State{}
+	for i := 0; i < 50; i++ {
+		state, _ = policy.Fail(state, now)
+	}
+	if have := state.RetryAfter(now); have != policy.MaxDelay {
+		t.Errorf("retry after: have %s want %s", have, policy.MaxDelay)
+	}
+}
+
+func TestWindow(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	state := State{}
+	for i := 0; i < 5; i++ {
+		state, _ = testPolicy.Fail(state, now)
+	}
+
+	// Failures are forgotten once the window has passed without any
+	state, _ = testPolicy.Fail(state, now.Add(testPolicy.Window))
+	if state.Failures != 1 {
+		t.Errorf("failures: have %d want 1", state.Failures)
+	}
+
+	// but not while locked out
+	state = State{Failures: 8, LastFailureAt: now, LockedUntil: now.Add(time.Hour)}
+	state, locked := testPolicy.Fail(state, now.Add(30*time.Minute))
+	if state.Failures != 9 || locked {
+		t.Errorf("failures: have %d, locked %v want 9, false", state.Failures, locked)
+	}
+}
+
+func TestUndo(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name       string
+		failures   int
+		retryAfter time.Duration
+	}{
+		{"free", 2, 0},
+		{"first delay", 4, 0},
+		{"delayed", 6, 2 * time.Second},
+		{"first lockout", 8, 8 * time.Second},
+		{"locked out", 9, time.Hour},
+	}
+	for _, test := range tests {
+		state := State{}
+		for i := 0; i < test.failures; i++ {
+			state, _ = testPolicy.Fail(state, now)
+		}
+		state = testPolicy.Undo(state)
+		if state.Failures != test.failures-1 {
+			t.Errorf("%s: have %d failures want %d", test.name, state.Failures, test.failures-1)
+		}
+		if have := state.RetryAfter(now); have != test.retryAfter {
+			t.Errorf("%s: have retry after %s want %s", test.name, have, test.retryAfter)
+		}
+	}
+
+	if state := testPolicy.Undo(State{}); state.Failures != 0 {
+		t.Errorf("no failures: have %d failures want 0", state.Failures)
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name  string
+		state State
+		want  time.Duration
+	}{
+		{"never failed", State{}, 0},
+		{"lock expired", State{LockedUntil: now.Add(-time.Second)}, 0},
+		{"lock ends now", State{LockedUntil: now}, 0},
+		{"locked", State{LockedUntil: now.Add(90 * time.Second)}, 90 * time.Second},
+	}
+	for _, test := range tests {
+		if have := test.state.RetryAfter(now); have != test.want {
+			t.Errorf("%s: have %s want %s", test.name, have, test.want)
+		}
+	}
+}
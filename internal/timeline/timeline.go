@@ -0,0 +1,59 @@
+// Package timeline holds the pieces of the home timeline that don't need a
+// database. Chirps from ordinary accounts are fanned out to their followers'
+// inboxes when posted, while chirps from accounts with many followers are
+// read straight from the chirps table. Merge combines the two.
+package timeline
+
+import (
+	"bytes"
+
+	"github.com/google/uuid"
+	"github.com/jthughes/chirpynetwork/internal/database"
+)
+
+const (
+	// DefaultFanOutLimit is the follower count at which an account stops
+	// being fanned out on write.
+	DefaultFanOutLimit = 10000
+	// BackfillSize is how many of an account's recent chirps are copied
+	// into a new follower's inbox.
+	BackfillSize = 100
+)
+
+// Merge combines lists of chirps that are each ordered newest first into one
+// list ordered the same way. A chirp present in several lists is kept once.
+func Merge(lists ...[]database.Chirp) []database.Chirp {
+	merged := []database.Chirp{}
+	seen := map[uuid.UUID]bool{}
+	next := make([]int, len(lists))
+	for {
+		best := -1
+		for i, list := range lists {
+			if next[i] >= len(list) {
+				continue
+			}
+			if best == -1 || newer(list[next[i]], lists[best][next[best]]) {
+				best = i
+			}
+		}
+		if best == -1 {
+			return merged
+		}
+		chirp := lists[best][next[best]]
+		next[best]++
+		if seen[chirp.ID] {
+			continue
+		}
+		seen[chirp.ID] = true
+		merged = append(merged, chirp)
+	}
+}
+
+// newer reports whether a sorts before b in a (created_at, id) descending
+// list.
+func newer(a, b database.Chirp) bool {
+	if !a.CreatedAt.Equal(b.CreatedAt) {
+		return a.CreatedAt.After(b.CreatedAt)
+	}
+	return bytes.Compare(a.ID[:], b.ID[:]) > 0
+}
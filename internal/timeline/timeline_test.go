@@ -0,0 +1,48 @@
+package timeline
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jthughes/chirpynetwork/internal/database"
+)
+
+func TestMerge(t *testing.T) {
+	base := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	chirp := func(minutes int, id string) database.Chirp {
+		return database.Chirp{
+			ID:        uuid.MustParse(id),
+			CreatedAt: base.Add(time.Duration(minutes) * time.Minute),
+		}
+	}
+	a := chirp(5, "00000000-0000-0000-0000-00000000000a")
+	b := chirp(4, "00000000-0000-0000-0000-00000000000b")
+	c := chirp(3, "00000000-0000-0000-0000-00000000000c")
+	d := chirp(3, "00000000-0000-0000-0000-00000000000d")
+	e := chirp(1, "00000000-0000-0000-0000-00000000000e")
+
+	tests := []struct {
+		name  string
+		lists [][]database.Chirp
+		want  []database.Chirp
+	}{
+		{"empty", nil, []database.Chirp{}},
+		{"one list", [][]database.Chirp{{a, b}}, []database.Chirp{a, b}},
+		{"interleaved", [][]database.Chirp{{a, c, e}, {b, d}}, []database.Chirp{a, b, d, c, e}},
+		{"duplicates", [][]database.Chirp{{a, b, c}, {b, c, e}}, []database.Chirp{a, b, c, e}},
+		{"one side empty", [][]database.Chirp{{}, {d, e}}, []database.Chirp{d, e}},
+	}
+	for _, test := range tests {
+		result := Merge(test.lists...)
+		if len(result) != len(test.want) {
+			t.Errorf("%s: have %d chirps want %d", test.name, len(result), len(test.want))
+			continue
+		}
+		for i := range result {
+			if result[i].ID != test.want[i].ID {
+				t.Errorf("%s: chirp %d: have '%s' want '%s'", test.name, i, result[i].ID, test.want[i].ID)
+			}
+		}
+	}
+}
@@ -0,0 +1,184 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jthughes/chirpynetwork/internal/database"
+	"github.com/jthughes/chirpynetwork/internal/pagination"
+)
+
+type Like struct {
+	UserID    uuid.UUID `json:"user_id"`
+	ChirpID   uuid.UUID `json:"chirp_id"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func dbLikeToLike(dbLike database.Like) Like {
+	return Like{
+		UserID:    dbLike.UserID,
+		ChirpID:   dbLike.ChirpID,
+		CreatedAt: dbLike.CreatedAt,
+	}
+}
+
+// ChirpLike is a like in the list of a chirp's likes, with the public
+// profile of the user who liked it.
+type ChirpLike struct {
+	Like
+	User Profile `json:"user"`
+}
+
+func (cfg *apiConfig) handlerLikeChirp(w http.ResponseWriter, r *http.Request) {
+
+	userID, err := cfg.authenticateRequest(r)
+	if err != nil {
+		ResponseError(w, err, "User not authenticated", http.StatusUnauthorized)
+		return
+	}
+
+	chirpID, err := uuid.Parse(r.PathValue("chirpID"))
+	if err != nil {
+		ResponseError(w, err, "Error parsing chirp id", http.StatusBadRequest)
+		return
+	}
+
+	_, err = cfg.getVisibleChirp(context.Background(), chirpID, uuid.NullUUID{UUID: userID, Valid: true})
+	if err != nil {
+		ResponseError(w, err, "Chirp ID not found", http.StatusNotFound)
+		return
+	}
+
+	tx, err := cfg.dbConn.BeginTx(context.Background(), nil)
+	if err != nil {
+		ResponseError(w, err, "Error liking chirp", http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.db.WithTx(tx)
+
+	// Only count the like if it wasn't already there
+	created, err := qtx.CreateLike(context.Background(), database.CreateLikeParams{
+		UserID:    userID,
+		ChirpID:   chirpID,
+		CreatedAt: time.Now(),
+	})
+	if err != nil {
+		ResponseError(w, err, "Error liking chirp", http.StatusInternalServerError)
+		return
+	}
+	if created > 0 {
+		err = qtx.IncrementChirpLikeCount(context.Background(), chirpID)
+		if err != nil {
+			ResponseError(w, err, "Error liking chirp", http.StatusInternalServerError)
+			return
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		ResponseError(w, err, "Error liking chirp", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (cfg *apiConfig) handlerUnlikeChirp(w http.ResponseWriter, r *http.Request) {
+
+	userID, err := cfg.authenticateRequest(r)
+	if err != nil {
+		ResponseError(w, err, "User not authenticated", http.StatusUnauthorized)
+		return
+	}
+
+	chirpID, err := uuid.Parse(r.PathValue("chirpID"))
+	if err != nil {
+		ResponseError(w, err, "Error parsing chirp id", http.StatusBadRequest)
+		return
+	}
+
+	tx, err := cfg.dbConn.BeginTx(context.Background(), nil)
+	if err != nil {
+		ResponseError(w, err, "Error unliking chirp", http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.db.WithTx(tx)
+
+	deleted, err := qtx.DeleteLike(context.Background(), database.DeleteLikeParams{
+		UserID:  userID,
+		ChirpID: chirpID,
+	})
+	if err != nil {
+		ResponseError(w, err, "Error unliking chirp", http.StatusInternalServerError)
+		return
+	}
+	if deleted > 0 {
+		err = qtx.DecrementChirpLikeCount(context.Background(), chirpID)
+		if err != nil {
+			ResponseError(w, err, "Error unliking chirp", http.StatusInternalServerError)
+			return
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		ResponseError(w, err, "Error unliking chirp", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (cfg *apiConfig) handlerGetChirpLikes(w http.ResponseWriter, r *http.Request) {
+
+	chirpID, err := uuid.Parse(r.PathValue("chirpID"))
+	if err != nil {
+		ResponseError(w, err, "Error parsing chirp id", http.StatusBadRequest)
+		return
+	}
+
+	page, err := pagination.ParseRequest(r.URL.Query())
+	if err != nil {
+		ResponseError(w, err, "Invalid pagination parameters", http.StatusBadRequest)
+		return
+	}
+
+	_, err = cfg.getVisibleChirp(context.Background(), chirpID, cfg.optionalViewer(r))
+	if err != nil {
+		ResponseError(w, err, "Chirp ID not found", http.StatusNotFound)
+		return
+	}
+
+	// Fetch one extra row to find out whether there is a next page
+	likes, err := cfg.db.ListChirpLikes(context.Background(), database.ListChirpLikesParams{
+		ChirpID:         chirpID,
+		HasCursor:       page.HasCursor,
+		CursorCreatedAt: page.Cursor.CreatedAt,
+		CursorID:        page.Cursor.ID,
+		RowLimit:        int32(page.Limit + 1),
+	})
+	if err != nil {
+		ResponseError(w, err, "Error retrieving likes", http.StatusInternalServerError)
+		return
+	}
+	likes, next := pagination.Trim(likes, page.Limit, func(row database.ListChirpLikesRow) pagination.Cursor {
+		return pagination.Cursor{CreatedAt: row.Like.CreatedAt, ID: row.Like.UserID}
+	})
+
+	out := []ChirpLike{}
+	for _, row := range likes {
+		out = append(out, ChirpLike{
+			Like: dbLikeToLike(row.Like),
+			User: dbUserToProfile(row.User),
+		})
+	}
+
+	SetNextCursor(w, r, next)
+	data, err := json.Marshal(out)
+	SetJSONResponse(w, http.StatusOK, data, err)
+}
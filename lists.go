@@ -0,0 +1,534 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jthughes/chirpynetwork/internal/database"
+	"github.com/jthughes/chirpynetwork/internal/pagination"
+	"github.com/jthughes/chirpynetwork/internal/textlen"
+)
+
+// Limits on lists, with lengths in user-perceived characters
+const (
+	listNameLengthLimit        = 25
+	listDescriptionLengthLimit = 100
+	listMemberLimit            = 5000
+)
+
+type List struct {
+	ID          uuid.UUID `json:"id"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+	OwnerID     uuid.UUID `json:"owner_id"`
+	Name        string    `json:"name"`
+	Description string    `json:"description"`
+	Private     bool      `json:"private"`
+	MemberCount int32     `json:"member_count"`
+}
+
+func dbListToList(dbList database.List) List {
+	return List{
+		ID:          dbList.ID,
+		CreatedAt:   dbList.CreatedAt,
+		UpdatedAt:   dbList.UpdatedAt,
+		OwnerID:     dbList.OwnerID,
+		Name:        dbList.Name,
+		Description: dbList.Description,
+		Private:     dbList.Private,
+		MemberCount: dbList.MemberCount,
+	}
+}
+
+type ListMember struct {
+	ListID    uuid.UUID `json:"list_id"`
+	UserID    uuid.UUID `json:"user_id"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func dbListMemberToListMember(dbMember database.ListMember) ListMember {
+	return ListMember{
+		ListID:    dbMember.ListID,
+		UserID:    dbMember.UserID,
+		CreatedAt: dbMember.CreatedAt,
+	}
+}
+
+// listRequest is the body of requests creating or replacing a list.
+type listRequest struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Private     bool   `json:"private"`
+}
+
+// check trims the name and description of a list and checks them against
+// their limits.
+func (req *listRequest) check() error {
+	req.Name = strings.TrimSpace(req.Name)
+	req.Description = strings.TrimSpace(req.Description)
+	if req.Name == "" {
+		return fmt.Errorf("name is required")
+	}
+	if textlen.Graphemes(req.Name) > listNameLengthLimit {
+		return fmt.Errorf("name must be at most %d characters", listNameLengthLimit)
+	}
+	if textlen.Graphemes(req.Description) > listDescriptionLengthLimit {
+		return fmt.Errorf("description must be at most %d characters", listDescriptionLengthLimit)
+	}
+	return nil
+}
+
+// getVisibleList fetches a list on behalf of viewer. Private lists are
+// reported as missing to everyone but their owner.
+func (cfg *apiConfig) getVisibleList(ctx context.Context, listID uuid.UUID, viewer uuid.NullUUID) (database.List, error) {
+	dbList, err := cfg.db.GetListByID(ctx, listID)
+	if err != nil {
+		return database.List{}, err
+	}
+	if dbList.Private && (!viewer.Valid || viewer.UUID != dbList.OwnerID) {
+		return database.List{}, sql.ErrNoRows
+	}
+	return dbList, nil
+}
+
+// getOwnedList fetches a list for a request that changes it, writing the
+// error response if the list isn't there or the user doesn't own it.
+func (cfg *apiConfig) getOwnedList(w http.ResponseWriter, r *http.Request, userID uuid.UUID) (database.List, bool) {
+	listID, err := uuid.Parse(r.PathValue("listID"))
+	if err != nil {
+		ResponseError(w, err, "Error parsing list id", http.StatusBadRequest)
+		return database.List{}, false
+	}
+	dbList, err := cfg.getVisibleList(context.Background(), listID, uuid.NullUUID{UUID: userID, Valid: true})
+	if errors.Is(err, sql.ErrNoRows) {
+		ResponseError(w, err, "List not found", http.StatusNotFound)
+		return database.List{}, false
+	}
+	if err != nil {
+		ResponseError(w, err, "Error retrieving list", http.StatusInternalServerError)
+		return database.List{}, false
+	}
+	if dbList.OwnerID != userID {
+		ResponseError(w, nil, "User not authorized", http.StatusForbidden)
+		return database.List{}, false
+	}
+	return dbList, true
+}
+
+func (cfg *apiConfig) handlerCreateList(w http.ResponseWriter, r *http.Request) {
+
+	userID, err := cfg.authenticateRequest(r)
+	if err != nil {
+		ResponseError(w, err, "User not authenticated", http.StatusUnauthorized)
+		return
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	test := listRequest{}
+	err = decoder.Decode(&test)
+	if err != nil {
+		ResponseError(w, err, "Error decoding request", http.StatusBadRequest)
+		return
+	}
+	err = test.check()
+	if err != nil {
+		ResponseError(w, err, fmt.Sprintf("Invalid list: %s", err), http.StatusBadRequest)
+		return
+	}
+
+	now := time.Now()
+	dbList, err := cfg.db.CreateList(context.Background(), database.CreateListParams{
+		ID:          uuid.New(),
+		CreatedAt:   now,
+		UpdatedAt:   now,
+		OwnerID:     userID,
+		Name:        test.Name,
+		Description: test.Description,
+		Private:     test.Private,
+	})
+	if err != nil {
+		ResponseError(w, err, "Error creating list", http.StatusInternalServerError)
+		return
+	}
+
+	data, err := json.Marshal(dbListToList(dbList))
+	SetJSONResponse(w, http.StatusCreated, data, err)
+}
+
+// handlerGetOwnLists lists the caller's lists, private ones included.
+func (cfg *apiConfig) handlerGetOwnLists(w http.ResponseWriter, r *http.Request) {
+
+	userID, err := cfg.authenticateRequest(r)
+	if err != nil {
+		ResponseError(w, err, "User not authenticated", http.StatusUnauthorized)
+		return
+	}
+
+	cfg.respondUserLists(w, r, userID, true)
+}
+
+// handlerGetUserLists lists another user's public lists. Users looking up
+// themselves also see their private lists.
+func (cfg *apiConfig) handlerGetUserLists(w http.ResponseWriter, r *http.Request) {
+
+	ownerID, err := uuid.Parse(r.PathValue("userID"))
+	if err != nil {
+		ResponseError(w, err, "Error parsing user id", http.StatusBadRequest)
+		return
+	}
+
+	_, err = cfg.db.GetUserById(context.Background(), ownerID)
+	if err != nil {
+		ResponseError(w, err, "User not found", http.StatusNotFound)
+		return
+	}
+
+	viewer := cfg.optionalViewer(r)
+	cfg.respondUserLists(w, r, ownerID, viewer.Valid && viewer.UUID == ownerID)
+}
+
+func (cfg *apiConfig) respondUserLists(w http.ResponseWriter, r *http.Request, ownerID uuid.UUID, includePrivate bool) {
+
+	page, err := pagination.ParseRequest(r.URL.Query())
+	if err != nil {
+		ResponseError(w, err, "Invalid pagination parameters", http.StatusBadRequest)
+		return
+	}
+
+	// Fetch one extra row to find out whether there is a next page
+	dbLists, err := cfg.db.ListUserLists(context.Background(), database.ListUserListsParams{
+		OwnerID:         ownerID,
+		IncludePrivate:  includePrivate,
+		HasCursor:       page.HasCursor,
+		CursorCreatedAt: page.Cursor.CreatedAt,
+		CursorID:        page.Cursor.ID,
+		RowLimit:        int32(page.Limit + 1),
+	})
+	if err != nil {
+		ResponseError(w, err, "Error retrieving lists", http.StatusInternalServerError)
+		return
+	}
+	dbLists, next := pagination.Trim(dbLists, page.Limit, func(dbList database.List) pagination.Cursor {
+		return pagination.Cursor{CreatedAt: dbList.CreatedAt, ID: dbList.ID}
+	})
+
+	out := []List{}
+	for _, item := range dbLists {
+		out = append(out, dbListToList(item))
+	}
+
+	SetNextCursor(w, r, next)
+	data, err := json.Marshal(out)
+	SetJSONResponse(w, http.StatusOK, data, err)
+}
+
+func (cfg *apiConfig) handlerGetList(w http.ResponseWriter, r *http.Request) {
+
+	listID, err := uuid.Parse(r.PathValue("listID"))
+	if err != nil {
+		ResponseError(w, err, "Error parsing list id", http.StatusBadRequest)
+		return
+	}
+
+	dbList, err := cfg.getVisibleList(context.Background(), listID, cfg.optionalViewer(r))
+	if err != nil {
+		ResponseError(w, err, "List not found", http.StatusNotFound)
+		return
+	}
+
+	data, err := json.Marshal(dbListToList(dbList))
+	SetJSONResponse(w, http.StatusOK, data, err)
+}
+
+func (cfg *apiConfig) handlerUpdateList(w http.ResponseWriter, r *http.Request) {
+
+	userID, err := cfg.authenticateRequest(r)
+	if err != nil {
+		ResponseError(w, err, "User not authenticated", http.StatusUnauthorized)
+		return
+	}
+
+	dbList, ok := cfg.getOwnedList(w, r, userID)
+	if !ok {
+		return
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	test := listRequest{}
+	err = decoder.Decode(&test)
+	if err != nil {
+		ResponseError(w, err, "Error decoding request", http.StatusBadRequest)
+		return
+	}
+	err = test.check()
+	if err != nil {
+		ResponseError(w, err, fmt.Sprintf("Invalid list: %s", err), http.StatusBadRequest)
+		return
+	}
+
+	dbList, err = cfg.db.UpdateList(context.Background(), database.UpdateListParams{
+		ID:          dbList.ID,
+		Name:        test.Name,
+		Description: test.Description,
+		Private:     test.Private,
+		UpdatedAt:   time.Now(),
+	})
+	if err != nil {
+		ResponseError(w, err, "Error updating list", http.StatusInternalServerError)
+		return
+	}
+
+	data, err := json.Marshal(dbListToList(dbList))
+	SetJSONResponse(w, http.StatusOK, data, err)
+}
+
+func (cfg *apiConfig) handlerDeleteList(w http.ResponseWriter, r *http.Request) {
+
+	userID, err := cfg.authenticateRequest(r)
+	if err != nil {
+		ResponseError(w, err, "User not authenticated", http.StatusUnauthorized)
+		return
+	}
+
+	dbList, ok := cfg.getOwnedList(w, r, userID)
+	if !ok {
+		return
+	}
+
+	err = cfg.db.DeleteList(context.Background(), dbList.ID)
+	if err != nil {
+		ResponseError(w, err, "Error deleting list", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (cfg *apiConfig) handlerGetListMembers(w http.ResponseWriter, r *http.Request) {
+
+	listID, err := uuid.Parse(r.PathValue("listID"))
+	if err != nil {
+		ResponseError(w, err, "Error parsing list id", http.StatusBadRequest)
+		return
+	}
+
+	page, err := pagination.ParseRequest(r.URL.Query())
+	if err != nil {
+		ResponseError(w, err, "Invalid pagination parameters", http.StatusBadRequest)
+		return
+	}
+
+	_, err = cfg.getVisibleList(context.Background(), listID, cfg.optionalViewer(r))
+	if err != nil {
+		ResponseError(w, err, "List not found", http.StatusNotFound)
+		return
+	}
+
+	// Fetch one extra row to find out whether there is a next page
+	members, err := cfg.db.ListListMembers(context.Background(), database.ListListMembersParams{
+		ListID:          listID,
+		HasCursor:       page.HasCursor,
+		CursorCreatedAt: page.Cursor.CreatedAt,
+		CursorID:        page.Cursor.ID,
+		RowLimit:        int32(page.Limit + 1),
+	})
+	if err != nil {
+		ResponseError(w, err, "Error retrieving list members", http.StatusInternalServerError)
+		return
+	}
+	members, next := pagination.Trim(members, page.Limit, func(member database.ListMember) pagination.Cursor {
+		return pagination.Cursor{CreatedAt: member.CreatedAt, ID: member.UserID}
+	})
+
+	out := []ListMember{}
+	for _, item := range members {
+		out = append(out, dbListMemberToListMember(item))
+	}
+
+	SetNextCursor(w, r, next)
+	data, err := json.Marshal(out)
+	SetJSONResponse(w, http.StatusOK, data, err)
+}
+
+func (cfg *apiConfig) handlerAddListMember(w http.ResponseWriter, r *http.Request) {
+
+	userID, err := cfg.authenticateRequest(r)
+	if err != nil {
+		ResponseError(w, err, "User not authenticated", http.StatusUnauthorized)
+		return
+	}
+
+	dbList, ok := cfg.getOwnedList(w, r, userID)
+	if !ok {
+		return
+	}
+
+	memberID, err := uuid.Parse(r.PathValue("userID"))
+	if err != nil {
+		ResponseError(w, err, "Error parsing user id", http.StatusBadRequest)
+		return
+	}
+
+	_, err = cfg.db.GetUserById(context.Background(), memberID)
+	if err != nil {
+		ResponseError(w, err, "User not found", http.StatusNotFound)
+		return
+	}
+
+	blocked, err := cfg.db.BlockExistsBetween(context.Background(), database.BlockExistsBetweenParams{
+		UserID:  userID,
+		OtherID: memberID,
+	})
+	if err != nil {
+		ResponseError(w, err, "Error adding list member", http.StatusInternalServerError)
+		return
+	}
+	if blocked {
+		ResponseError(w, nil, "Cannot add this user to a list", http.StatusForbidden)
+		return
+	}
+
+	tx, err := cfg.dbConn.BeginTx(context.Background(), nil)
+	if err != nil {
+		ResponseError(w, err, "Error adding list member", http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.db.WithTx(tx)
+
+	// Only count the member if they weren't already on the list
+	created, err := qtx.AddListMember(context.Background(), database.AddListMemberParams{
+		ListID:    dbList.ID,
+		UserID:    memberID,
+		CreatedAt: time.Now(),
+	})
+	if err != nil {
+		ResponseError(w, err, "Error adding list member", http.StatusInternalServerError)
+		return
+	}
+	if created > 0 {
+		counted, err := qtx.IncrementListMemberCount(context.Background(), database.IncrementListMemberCountParams{
+			ID:          dbList.ID,
+			MemberLimit: listMemberLimit,
+		})
+		if err != nil {
+			ResponseError(w, err, "Error adding list member", http.StatusInternalServerError)
+			return
+		}
+		if counted == 0 {
+			ResponseError(w, nil, fmt.Sprintf("Lists can have at most %d members", listMemberLimit), http.StatusBadRequest)
+			return
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		ResponseError(w, err, "Error adding list member", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (cfg *apiConfig) handlerRemoveListMember(w http.ResponseWriter, r *http.Request) {
+
+	userID, err := cfg.authenticateRequest(r)
+	if err != nil {
+		ResponseError(w, err, "User not authenticated", http.StatusUnauthorized)
+		return
+	}
+
+	dbList, ok := cfg.getOwnedList(w, r, userID)
+	if !ok {
+		return
+	}
+
+	memberID, err := uuid.Parse(r.PathValue("userID"))
+	if err != nil {
+		ResponseError(w, err, "Error parsing user id", http.StatusBadRequest)
+		return
+	}
+
+	tx, err := cfg.dbConn.BeginTx(context.Background(), nil)
+	if err != nil {
+		ResponseError(w, err, "Error removing list member", http.StatusInternalServerError)
+		return
+	}
+	defer tx.Rollback()
+	qtx := cfg.db.WithTx(tx)
+
+	deleted, err := qtx.RemoveListMember(context.Background(), database.RemoveListMemberParams{
+		ListID: dbList.ID,
+		UserID: memberID,
+	})
+	if err != nil {
+		ResponseError(w, err, "Error removing list member", http.StatusInternalServerError)
+		return
+	}
+	if deleted > 0 {
+		err = qtx.DecrementListMemberCount(context.Background(), dbList.ID)
+		if err != nil {
+			ResponseError(w, err, "Error removing list member", http.StatusInternalServerError)
+			return
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		ResponseError(w, err, "Error removing list member", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handlerGetListChirps gets the chirps of a list's members through the same
+// queries as GET /api/chirps, so the viewer's blocks, mutes and protected
+// accounts are applied the same way. Unlike GET /api/chirps, the newest
+// chirps come first unless sort=asc is given.
+func (cfg *apiConfig) handlerGetListChirps(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	listID, err := uuid.Parse(r.PathValue("listID"))
+	if err != nil {
+		ResponseError(w, err, "Error parsing list id", http.StatusBadRequest)
+		return
+	}
+
+	page, err := pagination.ParseRequest(query)
+	if err != nil {
+		ResponseError(w, err, "Invalid pagination parameters", http.StatusBadRequest)
+		return
+	}
+
+	viewer := cfg.optionalViewer(r)
+	_, err = cfg.getVisibleList(context.Background(), listID, viewer)
+	if err != nil {
+		ResponseError(w, err, "List not found", http.StatusNotFound)
+		return
+	}
+
+	desc := query.Get("sort") != "asc"
+	dbChirps, next, err := cfg.getChirpsPage(context.Background(), viewer, uuid.NullUUID{UUID: listID, Valid: true}, page, desc)
+	if err != nil {
+		ResponseError(w, err, "Error retrieving chirps", http.StatusInternalServerError)
+		return
+	}
+
+	out, err := cfg.buildChirps(context.Background(), dbChirps, viewer)
+	if err != nil {
+		ResponseError(w, err, "Error retrieving chirp details", http.StatusInternalServerError)
+		return
+	}
+
+	SetNextCursor(w, r, next)
+	data, err := json.Marshal(out)
+	SetJSONResponse(w, http.StatusOK, data, err)
+}
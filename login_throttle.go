@@ -0,0 +1,238 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jthughes/chirpynetwork/internal/database"
+	"github.com/jthughes/chirpynetwork/internal/throttle"
+)
+
+// Failed logins are counted against the account tried and the address they
+// came from. Addresses get more room, since many users can share one.
+var (
+	accountLoginPolicy = throttle.Policy{
+		FreeFailures:    5,
+		BaseDelay:       time.Second,
+		MaxDelay:        5 * time.Minute,
+		LockoutFailures: 10,
+		LockoutDuration: 15 * time.Minute,
+		Window:          15 * time.Minute,
+	}
+	ipLoginPolicy = throttle.Policy{
+		FreeFailures:    20,
+		BaseDelay:       time.Second,
+		MaxDelay:        5 * time.Minute,
+		LockoutFailures: 100,
+		LockoutDuration: time.Hour,
+		Window:          time.Hour,
+	}
+)
+
+const loginThrottleGCInterval = time.Hour
+
+// loginThrottle is one of the counters a login attempt is checked against.
+type loginThrottle struct {
+	Kind    string
+	Subject string
+	Policy  throttle.Policy
+}
+
+// loginThrottles returns the counters for an attempt to log in as email from
+// the address r came from.
+func (cfg *apiConfig) loginThrottles(r *http.Request, email string) []loginThrottle {
+	return []loginThrottle{
+		{Kind: "account", Subject: strings.ToLower(strings.TrimSpace(email)), Policy: accountLoginPolicy},
+		{Kind: "ip", Subject: cfg.clientIP(r), Policy: ipLoginPolicy},
+	}
+}
+
+func dbLoginThrottleToState(dbThrottle database.LoginThrottle) throttle.State {
+	return throttle.State{
+		Failures:      int(dbThrottle.Failures),
+		LastFailureAt: dbThrottle.LastFailureAt,
+		LockedUntil:   dbThrottle.LockedUntil.Time,
+	}
+}
+
+// loginAttempt is a login attempt that has been counted as a failure before
+// the password is checked, so that attempts made at the same time can't all
+// slip in before any of them fails.
+type loginAttempt struct {
+	// Wait is how long until an attempt is allowed, if this one was refused
+	// without being counted.
+	Wait time.Duration
+	// Next is how long until another attempt is allowed if this one fails.
+	Next time.Duration
+	// lockouts are the counters this attempt locks if it fails.
+	lockouts []loginLockout
+}
+
+type loginLockout struct {
+	throttle loginThrottle
+	state    throttle.State
+}
+
+// reserveLoginAttempt counts an attempt as a failure against every counter,
+// unless one of them is holding attempts off, in which case nothing is
+// counted and the attempt's Wait is set. The counters are locked while they
+// are checked and counted, so every instance sees each attempt.
+func (cfg *apiConfig) reserveLoginAttempt(ctx context.Context, throttles []loginThrottle) (loginAttempt, error) {
+	tx, err := cfg.dbConn.BeginTx(ctx, nil)
+	if err != nil {
+		return loginAttempt{}, err
+	}
+	defer tx.Rollback()
+	qtx := cfg.db.WithTx(tx)
+
+	now := time.Now()
+	states := []throttle.State{}
+	attempt := loginAttempt{}
+	for _, t := range throttles {
+		dbThrottle, err := lockLoginThrottle(ctx, qtx, t, now)
+		if err != nil {
+			return loginAttempt{}, err
+		}
+		state := dbLoginThrottleToState(dbThrottle)
+		attempt.Wait = max(attempt.Wait, state.RetryAfter(now))
+		states = append(states, state)
+	}
+	if attempt.Wait > 0 {
+		return attempt, nil
+	}
+
+	for i, t := range throttles {
+		state, locked := t.Policy.Fail(states[i], now)
+		err = updateLoginThrottle(ctx, qtx, t, state)
+		if err != nil {
+			return loginAttempt{}, err
+		}
+		attempt.Next = max(attempt.Next, state.RetryAfter(now))
+		if locked {
+			attempt.lockouts = append(attempt.lockouts, loginLockout{t, state})
+		}
+	}
+	return attempt, tx.Commit()
+}
+
+// failLoginAttempt reports the lockouts a failed attempt started. The owner
+// of a locked account, if there is a user with that email, is notified.
+func (cfg *apiConfig) failLoginAttempt(ctx context.Context, attempt loginAttempt, userID uuid.NullUUID, ip string) error {
+	for _, lockout := range attempt.lockouts {
+		t, state := lockout.throttle, lockout.state
+		log.Printf("Security event: logins for %s %q locked until %s after %d failures", t.Kind, t.Subject, state.LockedUntil.Format(time.RFC3339), state.Failures)
+		if t.Kind != "account" || !userID.Valid {
+			continue
+		}
+		_, err := cfg.db.CreateSecurityNotification(ctx, database.CreateSecurityNotificationParams{
+			ID:        uuid.New(),
+			CreatedAt: state.LastFailureAt,
+			UserID:    userID.UUID,
+			Kind:      "account_locked",
+			Message:   fmt.Sprintf("Logins to your account were locked until %s after %d failed attempts.", state.LockedUntil.UTC().Format(time.RFC3339), state.Failures),
+			IpAddress: ip,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// succeedLoginAttempt takes back the failure counted for an attempt that
+// succeeded. The account's failures are forgotten altogether, but the
+// address only has this attempt taken back, so that one account the
+// attacker holds can't be used to reset it.
+func (cfg *apiConfig) succeedLoginAttempt(ctx context.Context, throttles []loginThrottle) error {
+	tx, err := cfg.dbConn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	qtx := cfg.db.WithTx(tx)
+
+	now := time.Now()
+	for _, t := range throttles {
+		if t.Kind == "account" {
+			err = qtx.DeleteLoginThrottle(ctx, database.DeleteLoginThrottleParams{
+				Kind:    t.Kind,
+				Subject: t.Subject,
+			})
+			if err != nil {
+				return err
+			}
+			continue
+		}
+		dbThrottle, err := lockLoginThrottle(ctx, qtx, t, now)
+		if err != nil {
+			return err
+		}
+		err = updateLoginThrottle(ctx, qtx, t, t.Policy.Undo(dbLoginThrottleToState(dbThrottle)))
+		if err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// lockLoginThrottle locks a counter for the rest of the transaction q is in,
+// creating it if it doesn't exist yet.
+func lockLoginThrottle(ctx context.Context, q *database.Queries, t loginThrottle, now time.Time) (database.LoginThrottle, error) {
+	err := q.EnsureLoginThrottle(ctx, database.EnsureLoginThrottleParams{
+		Kind:          t.Kind,
+		Subject:       t.Subject,
+		LastFailureAt: now,
+	})
+	if err != nil {
+		return database.LoginThrottle{}, err
+	}
+	return q.LockLoginThrottle(ctx, database.LockLoginThrottleParams{
+		Kind:    t.Kind,
+		Subject: t.Subject,
+	})
+}
+
+func updateLoginThrottle(ctx context.Context, q *database.Queries, t loginThrottle, state throttle.State) error {
+	return q.UpdateLoginThrottle(ctx, database.UpdateLoginThrottleParams{
+		Kind:          t.Kind,
+		Subject:       t.Subject,
+		Failures:      int32(state.Failures),
+		LastFailureAt: state.LastFailureAt,
+		LockedUntil:   sql.NullTime{Time: state.LockedUntil, Valid: !state.LockedUntil.IsZero()},
+	})
+}
+
+// setRetryAfter tells the client how many whole seconds to wait before
+// trying again.
+func setRetryAfter(w http.ResponseWriter, wait time.Duration) int {
+	seconds := int(math.Ceil(wait.Seconds()))
+	w.Header().Set("Retry-After", strconv.Itoa(seconds))
+	return seconds
+}
+
+// collectLoginThrottles deletes every interval the counters that are no
+// longer holding anything off and whose failures have been forgotten. It
+// runs until the process exits.
+func (cfg *apiConfig) collectLoginThrottles(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		now := time.Now()
+		_, err := cfg.db.DeleteStaleLoginThrottles(context.Background(), database.DeleteStaleLoginThrottlesParams{
+			StaleBefore: now.Add(-max(accountLoginPolicy.Window, ipLoginPolicy.Window)),
+			Now:         now,
+		})
+		if err != nil {
+			log.Printf("Error collecting login throttles: %s", err)
+		}
+		<-ticker.C
+	}
+}
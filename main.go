@@ -7,19 +7,31 @@ import (
 	"log"
 	"net/http"
 	"os"
+	"strconv"
 	"sync/atomic"
+	"time"
 
 	"github.com/joho/godotenv"
+	"github.com/jthughes/chirpynetwork/internal/auth"
 	"github.com/jthughes/chirpynetwork/internal/database"
+	"github.com/jthughes/chirpynetwork/internal/media"
+	"github.com/jthughes/chirpynetwork/internal/pagination"
+	"github.com/jthughes/chirpynetwork/internal/timeline"
 	_ "github.com/lib/pq"
 )
 
 type apiConfig struct {
 	fileserverHits atomic.Int32
 	db             *database.Queries
+	dbConn         *sql.DB
 	platform       string
-	secretKey      string
+	jwtKeys        *auth.KeySet
 	polkaKey       string
+	editWindow     time.Duration
+	fanOutLimit    int32
+	media          media.Store
+	mediaMaxBytes  int64
+	trustProxy     bool
 }
 
 func (cfg *apiConfig) middlewareMetricsInc(next http.Handler) http.Handler {
@@ -41,39 +53,133 @@ func main() {
 		fmt.Printf("Unable to open connection to database: %s\n", err)
 		os.Exit(1)
 	}
+	editWindow, err := minutesFromEnv("CHIRP_EDIT_WINDOW", 30)
+	if err != nil {
+		fmt.Printf("Invalid CHIRP_EDIT_WINDOW: %s\n", err)
+		os.Exit(1)
+	}
+	fanOutLimit, err := countFromEnv("FANOUT_FOLLOWER_LIMIT", timeline.DefaultFanOutLimit)
+	if err != nil {
+		fmt.Printf("Invalid FANOUT_FOLLOWER_LIMIT: %s\n", err)
+		os.Exit(1)
+	}
+	mediaStore, err := mediaStoreFromEnv()
+	if err != nil {
+		fmt.Printf("Invalid MEDIA_STORAGE settings: %s\n", err)
+		os.Exit(1)
+	}
+	mediaMaxBytes, err := countFromEnv("MEDIA_MAX_BYTES", defaultMediaMaxBytes)
+	if err != nil {
+		fmt.Printf("Invalid MEDIA_MAX_BYTES: %s\n", err)
+		os.Exit(1)
+	}
+	jwtKeys, err := jwtKeysFromEnv(os.Getenv("PLATFORM"))
+	if err != nil {
+		fmt.Printf("Invalid JWT_KEYS: %s\n", err)
+		os.Exit(1)
+	}
+	trustProxy, err := boolFromEnv("TRUST_PROXY", false)
+	if err != nil {
+		fmt.Printf("Invalid TRUST_PROXY: %s\n", err)
+		os.Exit(1)
+	}
 	apiCfg := apiConfig{
 		fileserverHits: atomic.Int32{},
 		db:             database.New(db),
+		dbConn:         db,
 		platform:       os.Getenv("PLATFORM"),
-		secretKey:      os.Getenv("SECRET_KEY"),
+		jwtKeys:        jwtKeys,
 		polkaKey:       os.Getenv("POLKA_KEY"),
+		editWindow:     editWindow,
+		fanOutLimit:    fanOutLimit,
+		media:          mediaStore,
+		mediaMaxBytes:  int64(mediaMaxBytes),
+		trustProxy:     trustProxy,
 	}
+	go apiCfg.collectMediaGarbage(mediaGCInterval)
+	go apiCfg.collectLoginThrottles(loginThrottleGCInterval)
+
 	serveMux := http.NewServeMux()
 	handler := apiCfg.middlewareMetricsInc(http.StripPrefix("/app", http.FileServer(http.Dir(filepathRoot))))
 	serveMux.Handle("/app/", handler)
 
 	serveMux.HandleFunc("GET /api/healthz", handlerReadiness)
+	serveMux.HandleFunc("GET /.well-known/jwks.json", apiCfg.handlerJWKS)
 	serveMux.HandleFunc("GET /admin/metrics", apiCfg.handlerFileserverHits)
 
 	serveMux.HandleFunc("POST /admin/reset", apiCfg.handlerResetUsers)
+	serveMux.HandleFunc("GET /admin/content_rules", apiCfg.requireScopes(apiCfg.handlerListContentRules, auth.ScopeAdmin))
+	serveMux.HandleFunc("POST /admin/content_rules", apiCfg.requireScopes(apiCfg.handlerCreateContentRule, auth.ScopeAdmin))
+	serveMux.HandleFunc("PUT /admin/content_rules/{ruleID}", apiCfg.requireScopes(apiCfg.handlerUpdateContentRule, auth.ScopeAdmin))
+	serveMux.HandleFunc("DELETE /admin/content_rules/{ruleID}", apiCfg.requireScopes(apiCfg.handlerDeleteContentRule, auth.ScopeAdmin))
+	serveMux.HandleFunc("GET /admin/flags", apiCfg.requireScopes(apiCfg.handlerListChirpFlags, auth.ScopeAdmin))
+	serveMux.HandleFunc("DELETE /admin/flags/{flagID}", apiCfg.requireScopes(apiCfg.handlerDismissChirpFlag, auth.ScopeAdmin))
 	serveMux.HandleFunc("POST /api/users", apiCfg.handlerCreateUser)
-	serveMux.HandleFunc("PUT /api/users", apiCfg.handlerUpdateLogin)
+	serveMux.HandleFunc("PUT /api/users", apiCfg.requireScopes(apiCfg.handlerUpdateLogin, auth.ScopeUsersWrite))
+	serveMux.HandleFunc("PUT /api/users/protected", apiCfg.requireScopes(apiCfg.handlerSetProtected, auth.ScopeUsersWrite))
+	serveMux.HandleFunc("PATCH /api/users/profile", apiCfg.requireScopes(apiCfg.handlerUpdateProfile, auth.ScopeUsersWrite))
+	serveMux.HandleFunc("GET /api/users/{handle}", apiCfg.handlerGetProfile)
+	serveMux.HandleFunc("POST /api/users/{userID}/follow", apiCfg.requireScopes(apiCfg.handlerFollowUser, auth.ScopeUsersWrite))
+	serveMux.HandleFunc("DELETE /api/users/{userID}/follow", apiCfg.requireScopes(apiCfg.handlerUnfollowUser, auth.ScopeUsersWrite))
+	serveMux.HandleFunc("GET /api/users/{userID}/followers", apiCfg.handlerGetFollowers)
+	serveMux.HandleFunc("GET /api/users/{userID}/following", apiCfg.handlerGetFollowing)
+	serveMux.HandleFunc("POST /api/users/{userID}/block", apiCfg.requireScopes(apiCfg.handlerBlockUser, auth.ScopeUsersWrite))
+	serveMux.HandleFunc("DELETE /api/users/{userID}/block", apiCfg.requireScopes(apiCfg.handlerUnblockUser, auth.ScopeUsersWrite))
+	serveMux.HandleFunc("POST /api/users/{userID}/mute", apiCfg.requireScopes(apiCfg.handlerMuteUser, auth.ScopeUsersWrite))
+	serveMux.HandleFunc("DELETE /api/users/{userID}/mute", apiCfg.requireScopes(apiCfg.handlerUnmuteUser, auth.ScopeUsersWrite))
+	serveMux.HandleFunc("GET /api/follow_requests", apiCfg.requireScopes(apiCfg.handlerListFollowRequests))
+	serveMux.HandleFunc("POST /api/follow_requests/{userID}/approve", apiCfg.requireScopes(apiCfg.handlerApproveFollowRequest, auth.ScopeUsersWrite))
+	serveMux.HandleFunc("POST /api/follow_requests/{userID}/deny", apiCfg.requireScopes(apiCfg.handlerDenyFollowRequest, auth.ScopeUsersWrite))
 
 	// serveMux.HandleFunc("POST /api/validate_chirp", handlerValidateChirp)
-	serveMux.HandleFunc("POST /api/chirps", apiCfg.handlerNewChirp)
+	serveMux.HandleFunc("POST /api/chirps", apiCfg.requireScopes(apiCfg.handlerNewChirp, auth.ScopeChirpsWrite))
 	serveMux.HandleFunc("GET /api/chirps", apiCfg.handlerGetAllChirps)
+	serveMux.HandleFunc("GET /api/chirps/search", apiCfg.handlerSearchChirps)
 	serveMux.HandleFunc("GET /api/chirps/{chirpID}", apiCfg.handlerGetChirpByID)
-	serveMux.HandleFunc("DELETE /api/chirps/{chirpID}", apiCfg.handlerDeleteChirp)
+	serveMux.HandleFunc("PUT /api/chirps/{chirpID}", apiCfg.requireScopes(apiCfg.handlerEditChirp, auth.ScopeChirpsWrite))
+	serveMux.HandleFunc("DELETE /api/chirps/{chirpID}", apiCfg.requireScopes(apiCfg.handlerDeleteChirp, auth.ScopeChirpsWrite))
+	serveMux.HandleFunc("GET /api/chirps/{chirpID}/revisions", apiCfg.handlerGetChirpRevisions)
+	serveMux.HandleFunc("GET /api/chirps/{chirpID}/thread", apiCfg.handlerGetChirpThread)
+	serveMux.HandleFunc("POST /api/chirps/{chirpID}/rechirp", apiCfg.requireScopes(apiCfg.handlerRechirp, auth.ScopeChirpsWrite))
+	serveMux.HandleFunc("DELETE /api/chirps/{chirpID}/rechirp", apiCfg.requireScopes(apiCfg.handlerUndoRechirp, auth.ScopeChirpsWrite))
+	serveMux.HandleFunc("POST /api/chirps/{chirpID}/like", apiCfg.requireScopes(apiCfg.handlerLikeChirp, auth.ScopeChirpsWrite))
+	serveMux.HandleFunc("DELETE /api/chirps/{chirpID}/like", apiCfg.requireScopes(apiCfg.handlerUnlikeChirp, auth.ScopeChirpsWrite))
+	serveMux.HandleFunc("GET /api/chirps/{chirpID}/likes", apiCfg.handlerGetChirpLikes)
+	serveMux.HandleFunc("POST /api/chirps/{chirpID}/bookmark", apiCfg.requireScopes(apiCfg.handlerBookmarkChirp, auth.ScopeChirpsWrite))
+	serveMux.HandleFunc("DELETE /api/chirps/{chirpID}/bookmark", apiCfg.requireScopes(apiCfg.handlerUnbookmarkChirp, auth.ScopeChirpsWrite))
+	serveMux.HandleFunc("GET /api/bookmarks", apiCfg.requireScopes(apiCfg.handlerGetBookmarks))
+	serveMux.HandleFunc("GET /api/tags/{tag}/chirps", apiCfg.handlerGetTagChirps)
+	serveMux.HandleFunc("GET /api/timeline", apiCfg.requireScopes(apiCfg.handlerGetTimeline))
+	serveMux.HandleFunc("POST /api/lists", apiCfg.requireScopes(apiCfg.handlerCreateList, auth.ScopeUsersWrite))
+	serveMux.HandleFunc("GET /api/lists", apiCfg.requireScopes(apiCfg.handlerGetOwnLists))
+	serveMux.HandleFunc("GET /api/users/{userID}/lists", apiCfg.handlerGetUserLists)
+	serveMux.HandleFunc("GET /api/lists/{listID}", apiCfg.handlerGetList)
+	serveMux.HandleFunc("PUT /api/lists/{listID}", apiCfg.requireScopes(apiCfg.handlerUpdateList, auth.ScopeUsersWrite))
+	serveMux.HandleFunc("DELETE /api/lists/{listID}", apiCfg.requireScopes(apiCfg.handlerDeleteList, auth.ScopeUsersWrite))
+	serveMux.HandleFunc("GET /api/lists/{listID}/members", apiCfg.handlerGetListMembers)
+	serveMux.HandleFunc("PUT /api/lists/{listID}/members/{userID}", apiCfg.requireScopes(apiCfg.handlerAddListMember, auth.ScopeUsersWrite))
+	serveMux.HandleFunc("DELETE /api/lists/{listID}/members/{userID}", apiCfg.requireScopes(apiCfg.handlerRemoveListMember, auth.ScopeUsersWrite))
+	serveMux.HandleFunc("GET /api/lists/{listID}/chirps", apiCfg.handlerGetListChirps)
+	serveMux.HandleFunc("POST /api/media", apiCfg.requireScopes(apiCfg.handlerUploadMedia, auth.ScopeChirpsWrite))
+	serveMux.HandleFunc("GET /media/{mediaID}", apiCfg.handlerGetMediaFile)
+	serveMux.HandleFunc("GET /media/{mediaID}/thumbnail", apiCfg.handlerGetMediaThumbnail)
 
 	serveMux.HandleFunc("POST /api/login", apiCfg.handlerLogin)
 	serveMux.HandleFunc("POST /api/refresh", apiCfg.handlerRefresh)
 	serveMux.HandleFunc("POST /api/revoke", apiCfg.handlerRevoke)
+	serveMux.HandleFunc("GET /api/sessions", apiCfg.requireScopes(apiCfg.handlerGetSessions))
+	serveMux.HandleFunc("DELETE /api/sessions", apiCfg.requireScopes(apiCfg.handlerRevokeAllSessions, auth.ScopeUsersWrite))
+	serveMux.HandleFunc("DELETE /api/sessions/{sessionID}", apiCfg.requireScopes(apiCfg.handlerRevokeSession, auth.ScopeUsersWrite))
+	serveMux.HandleFunc("GET /api/security_notifications", apiCfg.requireScopes(apiCfg.handlerGetSecurityNotifications))
+	serveMux.HandleFunc("POST /api/tokens", apiCfg.requireScopes(apiCfg.handlerCreateToken, auth.ScopeUsersWrite))
+	serveMux.HandleFunc("GET /api/tokens", apiCfg.requireScopes(apiCfg.handlerGetTokens))
+	serveMux.HandleFunc("DELETE /api/tokens/{tokenID}", apiCfg.requireScopes(apiCfg.handlerRevokeToken, auth.ScopeUsersWrite))
 
 	serveMux.HandleFunc("POST /api/polka/webhooks", apiCfg.handlerWebhookPolkaUpgraded)
 
 	server := http.Server{
 		Addr:    ":" + port,
-		Handler: serveMux,
+		Handler: apiCfg.middlewareAuthenticate(serveMux),
 	}
 	err = server.ListenAndServe()
 	if err != nil {
@@ -82,6 +188,44 @@ func main() {
 	}
 }
 
+// minutesFromEnv reads a whole number of minutes from the named environment
+// variable, falling back to fallback when it is unset.
+func minutesFromEnv(key string, fallback int) (time.Duration, error) {
+	value := os.Getenv(key)
+	if value == "" {
+		return time.Duration(fallback) * time.Minute, nil
+	}
+	minutes, err := strconv.Atoi(value)
+	if err != nil || minutes < 0 {
+		return 0, fmt.Errorf("expected a whole number of minutes, got %q", value)
+	}
+	return time.Duration(minutes) * time.Minute, nil
+}
+
+func countFromEnv(key string, fallback int32) (int32, error) {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback, nil
+	}
+	count, err := strconv.ParseInt(value, 10, 32)
+	if err != nil || count < 0 {
+		return 0, fmt.Errorf("expected a whole number, got %q", value)
+	}
+	return int32(count), nil
+}
+
+func boolFromEnv(key string, fallback bool) (bool, error) {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback, nil
+	}
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, fmt.Errorf("expected true or false, got %q", value)
+	}
+	return b, nil
+}
+
 func handlerReadiness(w http.ResponseWriter, r *http.Request) {
 	w.Header().Add("Content-Type", "text/plain; charset=utf-8")
 	w.WriteHeader(http.StatusOK)
@@ -89,8 +233,15 @@ func handlerReadiness(w http.ResponseWriter, r *http.Request) {
 }
 
 func ResponseError(w http.ResponseWriter, err error, description string, statusCode int) {
+	ResponseErrorDetail(w, err, description, statusCode, nil)
+}
+
+// ResponseErrorDetail is ResponseError with extra machine-readable
+// information about the failure, sent as the "detail" field.
+func ResponseErrorDetail(w http.ResponseWriter, err error, description string, statusCode int, detail any) {
 	type responseFailure struct {
-		Error string `json:"error"`
+		Error  string `json:"error"`
+		Detail any    `json:"detail,omitempty"`
 	}
 	// Log if server error
 	var error_msg string
@@ -103,7 +254,8 @@ func ResponseError(w http.ResponseWriter, err error, description string, statusC
 
 	// Attempt to create formated error message
 	data, err := json.Marshal(responseFailure{
-		Error: error_msg,
+		Error:  error_msg,
+		Detail: detail,
 	})
 
 	// Just error out if fail to create formatted error message
@@ -129,6 +281,26 @@ func SetJSONResponse(w http.ResponseWriter, statusCode int, jsonData []byte, err
 	w.Write(jsonData)
 }
 
+// SetNextCursor advertises the following page of a paginated response through
+// the X-Next-Cursor and Link headers. It must be called before the status code
+// is written.
+func SetNextCursor(w http.ResponseWriter, r *http.Request, next *pagination.Cursor) {
+	if next == nil {
+		return
+	}
+	setNextPage(w, r, next.Encode())
+}
+
+// SetNextOffset is SetNextCursor for offset-paged responses.
+func SetNextOffset(w http.ResponseWriter, r *http.Request, next int) {
+	setNextPage(w, r, pagination.EncodeOffset(next))
+}
+
+func setNextPage(w http.ResponseWriter, r *http.Request, cursor string) {
+	w.Header().Set("X-Next-Cursor", cursor)
+	w.Header().Set("Link", fmt.Sprintf("<%s>; rel=\"next\"", pagination.NextURL(r.URL, cursor)))
+}
+
 func (cfg *apiConfig) handlerFileserverHits(w http.ResponseWriter, r *http.Request) {
 	w.Header().Add("Content-Type", "text/html; charset=utf-8")
 	w.WriteHeader(http.StatusOK)
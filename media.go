@@ -0,0 +1,407 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jthughes/chirpynetwork/internal/database"
+	"github.com/jthughes/chirpynetwork/internal/media"
+	"github.com/jthughes/chirpynetwork/internal/textlen"
+)
+
+// defaultMediaMaxBytes is the largest upload accepted when MEDIA_MAX_BYTES
+// isn't set.
+const defaultMediaMaxBytes = 5 << 20
+
+// Media files never change once stored, so they can be cached for a year.
+// Media only some users can see, from protected accounts or not yet posted,
+// is kept out of shared caches.
+const (
+	mediaCacheControl        = "public, max-age=31536000, immutable"
+	privateMediaCacheControl = "private, max-age=31536000, immutable"
+)
+
+const (
+	// chirpMediaLimit is how many media items a chirp can carry.
+	chirpMediaLimit = 4
+	// altTextLengthLimit bounds alt text, in user-perceived characters.
+	altTextLengthLimit = 1000
+	// mediaGracePeriod is how long an upload can wait to be attached to a
+	// chirp before it is garbage collected.
+	mediaGracePeriod = 24 * time.Hour
+	// mediaGCInterval is how often unattached media is garbage collected.
+	mediaGCInterval = time.Hour
+)
+
+type Media struct {
+	ID              uuid.UUID `json:"id"`
+	CreatedAt       time.Time `json:"created_at"`
+	UserID          uuid.UUID `json:"user_id"`
+	ContentType     string    `json:"content_type"`
+	Size            int32     `json:"size"`
+	Width           int32     `json:"width"`
+	Height          int32     `json:"height"`
+	Blurhash        string    `json:"blurhash"`
+	URL             string    `json:"url"`
+	ThumbnailURL    string    `json:"thumbnail_url"`
+	ThumbnailWidth  int32     `json:"thumbnail_width"`
+	ThumbnailHeight int32     `json:"thumbnail_height"`
+}
+
+func dbMediaToMedia(dbMedia database.Medium) Media {
+	return Media{
+		ID:              dbMedia.ID,
+		CreatedAt:       dbMedia.CreatedAt,
+		UserID:          dbMedia.UserID,
+		ContentType:     dbMedia.ContentType,
+		Size:            dbMedia.ByteSize,
+		Width:           dbMedia.Width,
+		Height:          dbMedia.Height,
+		Blurhash:        dbMedia.Blurhash,
+		URL:             fmt.Sprintf("/media/%s", dbMedia.ID),
+		ThumbnailURL:    fmt.Sprintf("/media/%s/thumbnail", dbMedia.ID),
+		ThumbnailWidth:  dbMedia.ThumbnailWidth,
+		ThumbnailHeight: dbMedia.ThumbnailHeight,
+	}
+}
+
+// ChirpMedia is media as attached to a chirp, with the alt text its author
+// gave it.
+type ChirpMedia struct {
+	Media
+	AltText string `json:"alt_text"`
+}
+
+// MediaAttachment is a request to attach uploaded media to a new chirp.
+type MediaAttachment struct {
+	ID      uuid.UUID `json:"id"`
+	AltText string    `json:"alt_text"`
+}
+
+// checkMediaAttachments checks the media requested for a chirp before it is
+// posted. Whether the author owns the media is checked when attaching it.
+func checkMediaAttachments(attachments []MediaAttachment) error {
+	if len(attachments) > chirpMediaLimit {
+		return fmt.Errorf("a chirp can have at most %d media items", chirpMediaLimit)
+	}
+	seen := map[uuid.UUID]bool{}
+	for _, attachment := range attachments {
+		if seen[attachment.ID] {
+			return fmt.Errorf("media %s is attached more than once", attachment.ID)
+		}
+		seen[attachment.ID] = true
+		if textlen.Graphemes(attachment.AltText) > altTextLengthLimit {
+			return fmt.Errorf("alt text must be at most %d characters", altTextLengthLimit)
+		}
+	}
+	return nil
+}
+
+// attachMedia attaches media to a chirp in the order given. Media can only
+// be attached by the user who uploaded it, and only to one chirp.
+func attachMedia(ctx context.Context, qtx *database.Queries, chirpID, userID uuid.UUID, attachments []MediaAttachment) error {
+	for i, attachment := range attachments {
+		attached, err := qtx.AttachMedia(ctx, database.AttachMediaParams{
+			ChirpID:  uuid.NullUUID{UUID: chirpID, Valid: true},
+			Position: int32(i),
+			AltText:  attachment.AltText,
+			ID:       attachment.ID,
+			UserID:   userID,
+		})
+		if err != nil {
+			return err
+		}
+		if attached == 0 {
+			return errMediaUnavailable
+		}
+	}
+	return nil
+}
+
+var errMediaUnavailable = errors.New("media not found or already attached")
+
+// getChirpMedia fetches the media attached to each of the chirps.
+func (cfg *apiConfig) getChirpMedia(ctx context.Context, chirpIDs []uuid.UUID) (map[uuid.UUID][]ChirpMedia, error) {
+	attached := map[uuid.UUID][]ChirpMedia{}
+	if len(chirpIDs) == 0 {
+		return attached, nil
+	}
+	dbMedia, err := cfg.db.ListChirpMedia(ctx, chirpIDs)
+	if err != nil {
+		return nil, err
+	}
+	for _, item := range dbMedia {
+		attached[item.ChirpID.UUID] = append(attached[item.ChirpID.UUID], ChirpMedia{
+			Media:   dbMediaToMedia(item),
+			AltText: item.AltText,
+		})
+	}
+	return attached, nil
+}
+
+// collectMediaGarbage deletes unattached media every interval: uploads that
+// were never posted within mediaGracePeriod, and media released by deleted
+// chirps. It runs until the process exits.
+func (cfg *apiConfig) collectMediaGarbage(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		dbMedia, err := cfg.db.DeleteUnattachedMedia(context.Background(), time.Now().Add(-mediaGracePeriod))
+		if err != nil {
+			log.Printf("Error collecting unattached media: %s", err)
+		}
+		for _, item := range dbMedia {
+			cfg.deleteMediaFiles(item.StorageKey, item.ThumbnailKey)
+		}
+		<-ticker.C
+	}
+}
+
+// mediaStoreFromEnv builds the media store selected by MEDIA_STORAGE: files
+// on local disk under MEDIA_DIR by default, or an S3-compatible bucket.
+func mediaStoreFromEnv() (media.Store, error) {
+	switch storage := os.Getenv("MEDIA_STORAGE"); storage {
+	case "", "local":
+		dir := os.Getenv("MEDIA_DIR")
+		if dir == "" {
+			dir = "media"
+		}
+		return media.NewLocalStore(dir)
+	case "s3":
+		store := &media.S3Store{
+			Endpoint:        os.Getenv("S3_ENDPOINT"),
+			Region:          os.Getenv("S3_REGION"),
+			Bucket:          os.Getenv("S3_BUCKET"),
+			AccessKeyID:     os.Getenv("S3_ACCESS_KEY_ID"),
+			SecretAccessKey: os.Getenv("S3_SECRET_ACCESS_KEY"),
+		}
+		if store.Region == "" {
+			store.Region = "us-east-1"
+		}
+		if store.Endpoint == "" {
+			store.Endpoint = fmt.Sprintf("https://s3.%s.amazonaws.com", store.Region)
+		}
+		if store.Bucket == "" {
+			return nil, fmt.Errorf("S3_BUCKET must be set")
+		}
+		return store, nil
+	default:
+		return nil, fmt.Errorf("expected local or s3, got %q", storage)
+	}
+}
+
+func (cfg *apiConfig) handlerUploadMedia(w http.ResponseWriter, r *http.Request) {
+
+	userID, err := cfg.authenticateRequest(r)
+	if err != nil {
+		ResponseError(w, err, "User not authenticated", http.StatusUnauthorized)
+		return
+	}
+
+	// Leave room for the multipart headers around the file
+	r.Body = http.MaxBytesReader(w, r.Body, cfg.mediaMaxBytes+64<<10)
+	reader, err := r.MultipartReader()
+	if err != nil {
+		ResponseError(w, err, "Expected a multipart/form-data upload", http.StatusBadRequest)
+		return
+	}
+	var data []byte
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			break
+		}
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			ResponseError(w, err, "Upload is too large", http.StatusRequestEntityTooLarge)
+			return
+		}
+		if err != nil {
+			ResponseError(w, err, "Error reading upload", http.StatusBadRequest)
+			return
+		}
+		if part.FormName() != "file" {
+			continue
+		}
+		data, err = io.ReadAll(io.LimitReader(part, cfg.mediaMaxBytes+1))
+		if errors.As(err, &maxBytesErr) || int64(len(data)) > cfg.mediaMaxBytes {
+			ResponseError(w, err, fmt.Sprintf("Upload is too large, limit is %d bytes", cfg.mediaMaxBytes), http.StatusRequestEntityTooLarge)
+			return
+		}
+		if err != nil {
+			ResponseError(w, err, "Error reading upload", http.StatusBadRequest)
+			return
+		}
+		break
+	}
+	if data == nil {
+		ResponseError(w, nil, "Missing file field", http.StatusBadRequest)
+		return
+	}
+
+	// The declared content type is ignored, Process sniffs the bytes
+	processed, err := media.Process(data)
+	if errors.Is(err, media.ErrUnsupportedType) {
+		ResponseError(w, err, "Unsupported media type, expected JPEG, PNG or GIF", http.StatusUnsupportedMediaType)
+		return
+	}
+	if err != nil {
+		ResponseError(w, err, "Invalid image", http.StatusBadRequest)
+		return
+	}
+
+	mediaID := uuid.New()
+	storageKey := fmt.Sprintf("%s.%s", mediaID, media.Extension(processed.ContentType))
+	thumbnailKey := fmt.Sprintf("%s_thumb.%s", mediaID, media.Extension(processed.ThumbnailContentType))
+	err = cfg.media.Put(r.Context(), storageKey, processed.ContentType, processed.Data)
+	if err == nil {
+		err = cfg.media.Put(r.Context(), thumbnailKey, processed.ThumbnailContentType, processed.Thumbnail)
+	}
+	if err != nil {
+		cfg.deleteMediaFiles(storageKey, thumbnailKey)
+		ResponseError(w, err, "Error storing media", http.StatusInternalServerError)
+		return
+	}
+
+	dbMedia, err := cfg.db.CreateMedia(context.Background(), database.CreateMediaParams{
+		ID:                   mediaID,
+		CreatedAt:            time.Now(),
+		UserID:               userID,
+		ContentType:          processed.ContentType,
+		ByteSize:             int32(len(processed.Data)),
+		Width:                int32(processed.Width),
+		Height:               int32(processed.Height),
+		StorageKey:           storageKey,
+		ThumbnailKey:         thumbnailKey,
+		ThumbnailContentType: processed.ThumbnailContentType,
+		ThumbnailWidth:       int32(processed.ThumbnailWidth),
+		ThumbnailHeight:      int32(processed.ThumbnailHeight),
+		Blurhash:             processed.Blurhash,
+	})
+	if err != nil {
+		cfg.deleteMediaFiles(storageKey, thumbnailKey)
+		ResponseError(w, err, "Error storing media", http.StatusInternalServerError)
+		return
+	}
+
+	data, err = json.Marshal(dbMediaToMedia(dbMedia))
+	SetJSONResponse(w, http.StatusCreated, data, err)
+}
+
+// deleteMediaFiles removes stored files that no media row points to. Failures
+// are only logged, leaving the file behind.
+func (cfg *apiConfig) deleteMediaFiles(keys ...string) {
+	for _, key := range keys {
+		err := cfg.media.Delete(context.Background(), key)
+		if err != nil {
+			log.Printf("Error deleting media file %s: %s", key, err)
+		}
+	}
+}
+
+func (cfg *apiConfig) handlerGetMediaFile(w http.ResponseWriter, r *http.Request) {
+	cfg.serveMedia(w, r, false)
+}
+
+func (cfg *apiConfig) handlerGetMediaThumbnail(w http.ResponseWriter, r *http.Request) {
+	cfg.serveMedia(w, r, true)
+}
+
+// mediaAccess decides whether viewer may see dbMedia, which they can if
+// they can see the chirp it is attached to, and returns how it may be
+// cached. Media not attached to a chirp is only shown to its uploader.
+func (cfg *apiConfig) mediaAccess(ctx context.Context, dbMedia database.Medium, viewer uuid.NullUUID) (string, error) {
+	if !dbMedia.ChirpID.Valid {
+		if viewer.Valid && viewer.UUID == dbMedia.UserID {
+			return privateMediaCacheControl, nil
+		}
+		return "", sql.ErrNoRows
+	}
+	dbChirp, err := cfg.getVisibleChirp(ctx, dbMedia.ChirpID.UUID, viewer)
+	if err != nil {
+		return "", err
+	}
+	author, err := cfg.db.GetUserById(ctx, dbChirp.UserID)
+	if err != nil {
+		return "", err
+	}
+	if author.Protected {
+		return privateMediaCacheControl, nil
+	}
+	return mediaCacheControl, nil
+}
+
+// serveMedia sends a stored media file, or its thumbnail, to users who can
+// see it, with headers that let it be cached indefinitely. Only media in
+// public chirps may be kept by shared caches such as CDNs.
+func (cfg *apiConfig) serveMedia(w http.ResponseWriter, r *http.Request, thumbnail bool) {
+
+	mediaID, err := uuid.Parse(r.PathValue("mediaID"))
+	if err != nil {
+		ResponseError(w, err, "Error parsing media id", http.StatusBadRequest)
+		return
+	}
+
+	dbMedia, err := cfg.db.GetMediaByID(context.Background(), mediaID)
+	if errors.Is(err, sql.ErrNoRows) {
+		ResponseError(w, err, "Media not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		ResponseError(w, err, "Error retrieving media", http.StatusInternalServerError)
+		return
+	}
+
+	cacheControl, err := cfg.mediaAccess(context.Background(), dbMedia, cfg.optionalViewer(r))
+	if errors.Is(err, sql.ErrNoRows) {
+		ResponseError(w, err, "Media not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		ResponseError(w, err, "Error retrieving media", http.StatusInternalServerError)
+		return
+	}
+
+	etag := strconv.Quote(mediaID.String())
+	if thumbnail {
+		etag = strconv.Quote(mediaID.String() + "-thumbnail")
+	}
+	if r.Header.Get("If-None-Match") == etag {
+		w.Header().Set("Cache-Control", cacheControl)
+		w.Header().Set("ETag", etag)
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	key, contentType := dbMedia.StorageKey, dbMedia.ContentType
+	if thumbnail {
+		key, contentType = dbMedia.ThumbnailKey, dbMedia.ThumbnailContentType
+	}
+	file, err := cfg.media.Get(r.Context(), key)
+	if errors.Is(err, media.ErrNotFound) {
+		ResponseError(w, err, "Media not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		ResponseError(w, err, "Error retrieving media", http.StatusInternalServerError)
+		return
+	}
+	defer file.Close()
+
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Cache-Control", cacheControl)
+	w.Header().Set("ETag", etag)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(http.StatusOK)
+	io.Copy(w, file)
+}
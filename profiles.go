@@ -0,0 +1,182 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jthughes/chirpynetwork/internal/database"
+	"github.com/jthughes/chirpynetwork/internal/handles"
+	"github.com/jthughes/chirpynetwork/internal/textlen"
+	"github.com/lib/pq"
+)
+
+// Limits on profile fields, in user-perceived characters
+const (
+	displayNameLengthLimit = 50
+	bioLengthLimit         = 160
+	locationLengthLimit    = 30
+	websiteLengthLimit     = 100
+)
+
+// Profile is the public view of a user, without their email or other
+// account details.
+type Profile struct {
+	ID             uuid.UUID `json:"id"`
+	CreatedAt      time.Time `json:"created_at"`
+	Handle         *string   `json:"handle"`
+	DisplayName    string    `json:"display_name"`
+	Bio            string    `json:"bio"`
+	Location       string    `json:"location"`
+	Website        string    `json:"website"`
+	IsChirpyRed    bool      `json:"is_chirpy_red"`
+	FollowerCount  int32     `json:"follower_count"`
+	FollowingCount int32     `json:"following_count"`
+	Protected      bool      `json:"protected"`
+}
+
+func dbUserToProfile(dbUser database.User) Profile {
+	return Profile{
+		ID:             dbUser.ID,
+		CreatedAt:      dbUser.CreatedAt,
+		Handle:         nullStringPtr(dbUser.Handle),
+		DisplayName:    dbUser.DisplayName,
+		Bio:            dbUser.Bio,
+		Location:       dbUser.Location,
+		Website:        dbUser.Website,
+		IsChirpyRed:    dbUser.IsChirpyRed,
+		FollowerCount:  dbUser.FollowerCount,
+		FollowingCount: dbUser.FollowingCount,
+		Protected:      dbUser.Protected,
+	}
+}
+
+func nullStringPtr(s sql.NullString) *string {
+	if !s.Valid {
+		return nil
+	}
+	return &s.String
+}
+
+// checkProfileText trims a profile field and checks it against its length
+// limit.
+func checkProfileText(field string, value *string, limit int) (sql.NullString, error) {
+	if value == nil {
+		return sql.NullString{}, nil
+	}
+	trimmed := strings.TrimSpace(*value)
+	if textlen.Graphemes(trimmed) > limit {
+		return sql.NullString{}, fmt.Errorf("%s must be at most %d characters", field, limit)
+	}
+	return sql.NullString{String: trimmed, Valid: true}, nil
+}
+
+// checkWebsite checks that a website is an http or https URL. An empty
+// website clears the field.
+func checkWebsite(value *string) (sql.NullString, error) {
+	website, err := checkProfileText("website", value, websiteLengthLimit)
+	if err != nil || !website.Valid || website.String == "" {
+		return website, err
+	}
+	parsed, err := url.Parse(website.String)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return sql.NullString{}, fmt.Errorf("website must be an http or https URL")
+	}
+	return website, nil
+}
+
+func (cfg *apiConfig) handlerGetProfile(w http.ResponseWriter, r *http.Request) {
+
+	// Users without a handle can still be looked up by id
+	var dbUser database.User
+	handle := r.PathValue("handle")
+	userID, err := uuid.Parse(handle)
+	if err == nil {
+		dbUser, err = cfg.db.GetUserById(context.Background(), userID)
+	} else {
+		dbUser, err = cfg.db.GetUserByHandle(context.Background(), handle)
+	}
+	if errors.Is(err, sql.ErrNoRows) {
+		ResponseError(w, err, "User not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		ResponseError(w, err, "Error retrieving user", http.StatusInternalServerError)
+		return
+	}
+
+	data, err := json.Marshal(dbUserToProfile(dbUser))
+	SetJSONResponse(w, http.StatusOK, data, err)
+}
+
+func (cfg *apiConfig) handlerUpdateProfile(w http.ResponseWriter, r *http.Request) {
+	type request struct {
+		Handle      *string `json:"handle"`
+		DisplayName *string `json:"display_name"`
+		Bio         *string `json:"bio"`
+		Location    *string `json:"location"`
+		Website     *string `json:"website"`
+	}
+
+	userID, err := cfg.authenticateRequest(r)
+	if err != nil {
+		ResponseError(w, err, "User not authenticated", http.StatusUnauthorized)
+		return
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	test := request{}
+	err = decoder.Decode(&test)
+	if err != nil {
+		ResponseError(w, err, "Error decoding request", http.StatusBadRequest)
+		return
+	}
+
+	// Fields left out of the request keep their current value
+	params := database.UpdateUserProfileParams{ID: userID}
+	if test.Handle != nil {
+		err = handles.Validate(*test.Handle)
+		if err != nil {
+			ResponseError(w, err, fmt.Sprintf("Invalid handle: %s", err), http.StatusBadRequest)
+			return
+		}
+		params.Handle = sql.NullString{String: *test.Handle, Valid: true}
+		params.HandleSkeleton = sql.NullString{String: handles.Skeleton(*test.Handle), Valid: true}
+	}
+	params.DisplayName, err = checkProfileText("display_name", test.DisplayName, displayNameLengthLimit)
+	if err == nil {
+		params.Bio, err = checkProfileText("bio", test.Bio, bioLengthLimit)
+	}
+	if err == nil {
+		params.Location, err = checkProfileText("location", test.Location, locationLengthLimit)
+	}
+	if err == nil {
+		params.Website, err = checkWebsite(test.Website)
+	}
+	if err != nil {
+		ResponseError(w, err, fmt.Sprintf("Invalid profile: %s", err), http.StatusBadRequest)
+		return
+	}
+
+	dbUser, err := cfg.db.UpdateUserProfile(context.Background(), params)
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+		ResponseError(w, err, "Handle is already taken", http.StatusConflict)
+		return
+	}
+	if err != nil {
+		ResponseError(w, err, "Error updating profile", http.StatusInternalServerError)
+		return
+	}
+
+	user := dbUserToUser(dbUser)
+	data, err := json.Marshal(user)
+	SetJSONResponse(w, http.StatusOK, data, err)
+}
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jthughes/chirpynetwork/internal/database"
+	"github.com/jthughes/chirpynetwork/internal/pagination"
+)
+
+// RechirpAttribution marks a chirp that appears in an author's timeline
+// because they rechirped it.
+type RechirpAttribution struct {
+	UserID    uuid.UUID `json:"user_id"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// getAuthorTimeline fetches one page of an author's chirps interleaved with
+// their rechirps, ordered by when each entered the timeline. Chirps hidden
+// from viewer by blocks, mutes or a protected account are left out, whether
+// the protected or blocking account wrote the chirp or rechirped it. The
+// returned attributions line up with the chirps and are nil for the author's
+// own.
+func (cfg *apiConfig) getAuthorTimeline(ctx context.Context, authorID uuid.UUID, viewer uuid.NullUUID, page pagination.Request, desc bool) ([]database.Chirp, []*RechirpAttribution, *pagination.Cursor, error) {
+	type entry struct {
+		chirp     database.Chirp
+		entryID   uuid.UUID
+		entryAt   time.Time
+		rechirped bool
+	}
+
+	// Fetch one extra row to find out whether there is a next page
+	entries := []entry{}
+	if desc {
+		rows, err := cfg.db.ListAuthorTimelineDesc(ctx, database.ListAuthorTimelineDescParams{
+			AuthorID:        authorID,
+			HasCursor:       page.HasCursor,
+			CursorCreatedAt: page.Cursor.CreatedAt,
+			CursorID:        page.Cursor.ID,
+			ViewerID:        viewer,
+			RowLimit:        int32(page.Limit + 1),
+		})
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		for _, row := range rows {
+			entries = append(entries, entry{row.Chirp, row.EntryID, row.EntryAt, row.Rechirped})
+		}
+	} else {
+		rows, err := cfg.db.ListAuthorTimelineAsc(ctx, database.ListAuthorTimelineAscParams{
+			AuthorID:        authorID,
+			HasCursor:       page.HasCursor,
+			CursorCreatedAt: page.Cursor.CreatedAt,
+			CursorID:        page.Cursor.ID,
+			ViewerID:        viewer,
+			RowLimit:        int32(page.Limit + 1),
+		})
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		for _, row := range rows {
+			entries = append(entries, entry{row.Chirp, row.EntryID, row.EntryAt, row.Rechirped})
+		}
+	}
+	entries, next := pagination.Trim(entries, page.Limit, func(e entry) pagination.Cursor {
+		return pagination.Cursor{CreatedAt: e.entryAt, ID: e.entryID}
+	})
+
+	dbChirps := []database.Chirp{}
+	attributions := []*RechirpAttribution{}
+	for _, e := range entries {
+		dbChirps = append(dbChirps, e.chirp)
+		if e.rechirped {
+			attributions = append(attributions, &RechirpAttribution{
+				UserID:    authorID,
+				CreatedAt: e.entryAt,
+			})
+		} else {
+			attributions = append(attributions, nil)
+		}
+	}
+	return dbChirps, attributions, next, nil
+}
+
+func (cfg *apiConfig) handlerRechirp(w http.ResponseWriter, r *http.Request) {
+
+	userID, err := cfg.authenticateRequest(r)
+	if err != nil {
+		ResponseError(w, err, "User not authenticated", http.StatusUnauthorized)
+		return
+	}
+
+	chirpID, err := uuid.Parse(r.PathValue("chirpID"))
+	if err != nil {
+		ResponseError(w, err, "Error parsing chirp id", http.StatusBadRequest)
+		return
+	}
+
+	_, err = cfg.getVisibleChirp(context.Background(), chirpID, uuid.NullUUID{UUID: userID, Valid: true})
+	if err != nil {
+		ResponseError(w, err, "Chirp ID not found", http.StatusNotFound)
+		return
+	}
+
+	// Rechirping twice is a no-op
+	err = cfg.db.CreateRechirp(context.Background(), database.CreateRechirpParams{
+		ID:        uuid.New(),
+		CreatedAt: time.Now(),
+		UserID:    userID,
+		ChirpID:   chirpID,
+	})
+	if err != nil {
+		ResponseError(w, err, "Error rechirping chirp", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (cfg *apiConfig) handlerUndoRechirp(w http.ResponseWriter, r *http.Request) {
+
+	userID, err := cfg.authenticateRequest(r)
+	if err != nil {
+		ResponseError(w, err, "User not authenticated", http.StatusUnauthorized)
+		return
+	}
+
+	chirpID, err := uuid.Parse(r.PathValue("chirpID"))
+	if err != nil {
+		ResponseError(w, err, "Error parsing chirp id", http.StatusBadRequest)
+		return
+	}
+
+	err = cfg.db.DeleteRechirp(context.Background(), database.DeleteRechirpParams{
+		UserID:  userID,
+		ChirpID: chirpID,
+	})
+	if err != nil {
+		ResponseError(w, err, "Error removing rechirp", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jthughes/chirpynetwork/internal/database"
+)
+
+// ChirpRevision is a body a chirp held before it was edited. CreatedAt is
+// when that body was written and ReplacedAt is when an edit superseded it.
+type ChirpRevision struct {
+	ID         uuid.UUID `json:"id"`
+	ChirpID    uuid.UUID `json:"chirp_id"`
+	Body       string    `json:"body"`
+	CreatedAt  time.Time `json:"created_at"`
+	ReplacedAt time.Time `json:"replaced_at"`
+}
+
+func dbRevisionToRevision(dbRevision database.ChirpRevision) ChirpRevision {
+	return ChirpRevision{
+		ID:         dbRevision.ID,
+		ChirpID:    dbRevision.ChirpID,
+		Body:       dbRevision.Body,
+		CreatedAt:  dbRevision.CreatedAt,
+		ReplacedAt: dbRevision.ReplacedAt,
+	}
+}
+
+func (cfg *apiConfig) handlerGetChirpRevisions(w http.ResponseWriter, r *http.Request) {
+
+	chirpID, err := uuid.Parse(r.PathValue("chirpID"))
+	if err != nil {
+		ResponseError(w, err, "Error parsing chirp id", http.StatusBadRequest)
+		return
+	}
+
+	_, err = cfg.getVisibleChirp(context.Background(), chirpID, cfg.optionalViewer(r))
+	if err != nil {
+		ResponseError(w, err, "Chirp ID not found", http.StatusNotFound)
+		return
+	}
+
+	revisions, err := cfg.db.GetChirpRevisions(context.Background(), chirpID)
+	if err != nil {
+		ResponseError(w, err, "Error retrieving chirp revisions", http.StatusInternalServerError)
+		return
+	}
+
+	out := []ChirpRevision{}
+	for _, item := range revisions {
+		out = append(out, dbRevisionToRevision(item))
+	}
+
+	data, err := json.Marshal(out)
+	SetJSONResponse(w, http.StatusOK, data, err)
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/jthughes/chirpynetwork/internal/database"
+	"github.com/jthughes/chirpynetwork/internal/pagination"
+	"github.com/jthughes/chirpynetwork/internal/search"
+)
+
+// SearchResult is a chirp matching a search, with the matching terms of its
+// body highlighted in Snippet using <mark> tags. The rest of Snippet is
+// HTML-escaped.
+type SearchResult struct {
+	Chirp
+	Snippet string  `json:"snippet"`
+	Rank    float32 `json:"rank"`
+}
+
+func (cfg *apiConfig) handlerSearchChirps(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	page, err := pagination.ParseOffsetRequest(query)
+	if err != nil {
+		ResponseError(w, err, "Invalid pagination parameters", http.StatusBadRequest)
+		return
+	}
+
+	parsed, err := search.Parse(query.Get("q"))
+	if err != nil {
+		ResponseError(w, err, "Invalid search query", http.StatusBadRequest)
+		return
+	}
+
+	viewer := cfg.optionalViewer(r)
+	params := database.SearchChirpsParams{
+		Query:     parsed.Text,
+		ViewerID:  viewer,
+		RowLimit:  int32(page.Limit + 1),
+		RowOffset: int32(page.Offset),
+	}
+	if parsed.From != nil {
+		params.AuthorID = uuid.NullUUID{UUID: *parsed.From, Valid: true}
+	}
+	if parsed.Since != nil {
+		params.Since = sql.NullTime{Time: *parsed.Since, Valid: true}
+	}
+	if parsed.Until != nil {
+		params.Until = sql.NullTime{Time: *parsed.Until, Valid: true}
+	}
+
+	// Fetch one extra row to find out whether there is a next page
+	rows, err := cfg.db.SearchChirps(context.Background(), params)
+	if err != nil {
+		ResponseError(w, err, "Error searching chirps", http.StatusInternalServerError)
+		return
+	}
+	hasNext := len(rows) > page.Limit
+	if hasNext {
+		rows = rows[:page.Limit]
+	}
+
+	dbChirps := []database.Chirp{}
+	for _, row := range rows {
+		dbChirps = append(dbChirps, row.Chirp)
+	}
+	chirps, err := cfg.buildChirps(context.Background(), dbChirps, viewer)
+	if err != nil {
+		ResponseError(w, err, "Error retrieving chirp details", http.StatusInternalServerError)
+		return
+	}
+
+	out := []SearchResult{}
+	for i, row := range rows {
+		out = append(out, SearchResult{
+			Chirp:   chirps[i],
+			Snippet: row.Snippet,
+			Rank:    row.Rank,
+		})
+	}
+
+	if hasNext {
+		SetNextOffset(w, r, page.Offset+page.Limit)
+	}
+	data, err := json.Marshal(out)
+	SetJSONResponse(w, http.StatusOK, data, err)
+}
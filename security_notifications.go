@@ -0,0 +1,72 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jthughes/chirpynetwork/internal/database"
+	"github.com/jthughes/chirpynetwork/internal/pagination"
+)
+
+// SecurityNotification tells a user about something that happened to their
+// account, such as logins to it being locked.
+type SecurityNotification struct {
+	ID        uuid.UUID `json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	Kind      string    `json:"kind"`
+	Message   string    `json:"message"`
+	IPAddress string    `json:"ip_address"`
+}
+
+func dbSecurityNotificationToSecurityNotification(dbNotification database.SecurityNotification) SecurityNotification {
+	return SecurityNotification{
+		ID:        dbNotification.ID,
+		CreatedAt: dbNotification.CreatedAt,
+		Kind:      dbNotification.Kind,
+		Message:   dbNotification.Message,
+		IPAddress: dbNotification.IpAddress,
+	}
+}
+
+func (cfg *apiConfig) handlerGetSecurityNotifications(w http.ResponseWriter, r *http.Request) {
+
+	userID, err := cfg.authenticateRequest(r)
+	if err != nil {
+		ResponseError(w, err, "User not authenticated", http.StatusUnauthorized)
+		return
+	}
+
+	page, err := pagination.ParseRequest(r.URL.Query())
+	if err != nil {
+		ResponseError(w, err, "Invalid pagination parameters", http.StatusBadRequest)
+		return
+	}
+
+	// Fetch one extra row to find out whether there is a next page
+	notifications, err := cfg.db.ListSecurityNotifications(context.Background(), database.ListSecurityNotificationsParams{
+		UserID:          userID,
+		HasCursor:       page.HasCursor,
+		CursorCreatedAt: page.Cursor.CreatedAt,
+		CursorID:        page.Cursor.ID,
+		RowLimit:        int32(page.Limit + 1),
+	})
+	if err != nil {
+		ResponseError(w, err, "Error retrieving security notifications", http.StatusInternalServerError)
+		return
+	}
+	notifications, next := pagination.Trim(notifications, page.Limit, func(notification database.SecurityNotification) pagination.Cursor {
+		return pagination.Cursor{CreatedAt: notification.CreatedAt, ID: notification.ID}
+	})
+
+	out := []SecurityNotification{}
+	for _, item := range notifications {
+		out = append(out, dbSecurityNotificationToSecurityNotification(item))
+	}
+
+	SetNextCursor(w, r, next)
+	data, err := json.Marshal(out)
+	SetJSONResponse(w, http.StatusOK, data, err)
+}
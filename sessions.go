@@ -0,0 +1,183 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jthughes/chirpynetwork/internal/database"
+	"github.com/jthughes/chirpynetwork/internal/textlen"
+)
+
+// Limits on what is recorded about the device of a session. Device labels
+// are counted in user-perceived characters; user agents, which the user
+// doesn't choose, are cut short in bytes.
+const (
+	deviceLabelLengthLimit = 100
+	userAgentLengthLimit   = 512
+)
+
+// Session is a login, which lasts as long as its refresh tokens are used.
+type Session struct {
+	ID          uuid.UUID `json:"id"`
+	CreatedAt   time.Time `json:"created_at"`
+	LastUsedAt  time.Time `json:"last_used_at"`
+	ExpiresAt   time.Time `json:"expires_at"`
+	DeviceLabel string    `json:"device_label"`
+	UserAgent   string    `json:"user_agent"`
+	IPAddress   string    `json:"ip_address"`
+	Current     bool      `json:"current"`
+}
+
+// sessionDevice is what a session records about the device it is used from.
+type sessionDevice struct {
+	Label     string
+	UserAgent string
+	IPAddress string
+}
+
+// requestDevice describes the device r was sent from, with the label the user
+// gave it.
+func (cfg *apiConfig) requestDevice(r *http.Request, label string) (sessionDevice, error) {
+	label = strings.TrimSpace(label)
+	if textlen.Graphemes(label) > deviceLabelLengthLimit {
+		return sessionDevice{}, fmt.Errorf("device label must be at most %d characters", deviceLabelLengthLimit)
+	}
+	userAgent := r.UserAgent()
+	if len(userAgent) > userAgentLengthLimit {
+		userAgent = strings.ToValidUTF8(userAgent[:userAgentLengthLimit], "")
+	}
+	return sessionDevice{
+		Label:     label,
+		UserAgent: userAgent,
+		IPAddress: cfg.clientIP(r),
+	}, nil
+}
+
+// clientIP returns the address r came from. Behind a reverse proxy, which
+// must be configured with TRUST_PROXY, that is the last address the proxy
+// added to X-Forwarded-For; anything before it was sent by the client and
+// can't be trusted.
+func (cfg *apiConfig) clientIP(r *http.Request) string {
+	if cfg.trustProxy {
+		forwarded := r.Header.Values("X-Forwarded-For")
+		if len(forwarded) > 0 {
+			hops := strings.Split(forwarded[len(forwarded)-1], ",")
+			if ip := net.ParseIP(strings.TrimSpace(hops[len(hops)-1])); ip != nil {
+				return ip.String()
+			}
+		}
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// revokeSessions logs userID out of every session but except, if given.
+func (cfg *apiConfig) revokeSessions(ctx context.Context, userID uuid.UUID, except uuid.NullUUID) error {
+	_, err := cfg.db.RevokeUserSessions(ctx, database.RevokeUserSessionsParams{
+		UserID:         userID,
+		ExceptFamilyID: except,
+		RevokedAt: sql.NullTime{
+			Time:  time.Now(),
+			Valid: true,
+		},
+	})
+	return err
+}
+
+func (cfg *apiConfig) handlerGetSessions(w http.ResponseWriter, r *http.Request) {
+
+	userID, sessionID, err := cfg.authenticateSession(r)
+	if err != nil {
+		ResponseError(w, err, "User not authenticated", http.StatusUnauthorized)
+		return
+	}
+
+	rows, err := cfg.db.ListUserSessions(context.Background(), database.ListUserSessionsParams{
+		UserID: userID,
+		Now:    time.Now(),
+	})
+	if err != nil {
+		ResponseError(w, err, "Error retrieving sessions", http.StatusInternalServerError)
+		return
+	}
+
+	out := []Session{}
+	for _, row := range rows {
+		out = append(out, Session{
+			ID:          row.RefreshToken.FamilyID,
+			CreatedAt:   row.SessionCreatedAt,
+			LastUsedAt:  row.RefreshToken.LastUsedAt,
+			ExpiresAt:   row.RefreshToken.ExpiresAt,
+			DeviceLabel: row.RefreshToken.DeviceLabel,
+			UserAgent:   row.RefreshToken.UserAgent,
+			IPAddress:   row.RefreshToken.IpAddress,
+			Current:     sessionID.Valid && sessionID.UUID == row.RefreshToken.FamilyID,
+		})
+	}
+
+	data, err := json.Marshal(out)
+	SetJSONResponse(w, http.StatusOK, data, err)
+}
+
+func (cfg *apiConfig) handlerRevokeSession(w http.ResponseWriter, r *http.Request) {
+
+	userID, err := cfg.authenticateRequest(r)
+	if err != nil {
+		ResponseError(w, err, "User not authenticated", http.StatusUnauthorized)
+		return
+	}
+
+	sessionID, err := uuid.Parse(r.PathValue("sessionID"))
+	if err != nil {
+		ResponseError(w, err, "Error parsing session id", http.StatusBadRequest)
+		return
+	}
+
+	revoked, err := cfg.db.RevokeUserSession(context.Background(), database.RevokeUserSessionParams{
+		FamilyID: sessionID,
+		UserID:   userID,
+		RevokedAt: sql.NullTime{
+			Time:  time.Now(),
+			Valid: true,
+		},
+	})
+	if err != nil {
+		ResponseError(w, err, "Error revoking session", http.StatusInternalServerError)
+		return
+	}
+	if revoked == 0 {
+		ResponseError(w, nil, "Session not found", http.StatusNotFound)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handlerRevokeAllSessions logs the user out everywhere, including the
+// session the request was made from.
+func (cfg *apiConfig) handlerRevokeAllSessions(w http.ResponseWriter, r *http.Request) {
+
+	userID, err := cfg.authenticateRequest(r)
+	if err != nil {
+		ResponseError(w, err, "User not authenticated", http.StatusUnauthorized)
+		return
+	}
+
+	err = cfg.revokeSessions(context.Background(), userID, uuid.NullUUID{})
+	if err != nil {
+		ResponseError(w, err, "Error revoking sessions", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,30 @@
+-- name: CreateBookmark :execrows
+INSERT INTO bookmarks (user_id, chirp_id, created_at)
+VALUES (
+    $1,
+    $2,
+    $3
+)
+ON CONFLICT (user_id, chirp_id) DO NOTHING;
+
+-- name: DeleteBookmark :execrows
+DELETE FROM bookmarks
+WHERE user_id = $1 AND chirp_id = $2;
+
+-- name: ListBookmarkedChirps :many
+-- Muted authors are kept, as the user chose to save these chirps
+SELECT sqlc.embed(chirps), bookmarks.created_at AS bookmarked_at
+FROM bookmarks
+JOIN chirps ON chirps.id = bookmarks.chirp_id
+WHERE bookmarks.user_id = @user_id
+AND (NOT @has_cursor::boolean OR (bookmarks.created_at, bookmarks.chirp_id) < (@cursor_created_at::timestamp, @cursor_id::uuid))
+AND NOT EXISTS (SELECT 1 FROM blocks WHERE blocks.blocker_id = chirps.user_id AND blocks.blocked_id = @user_id)
+AND (chirps.user_id = @user_id
+    OR NOT EXISTS (SELECT 1 FROM users authors WHERE authors.id = chirps.user_id AND authors.protected)
+    OR EXISTS (SELECT 1 FROM follows WHERE follows.follower_id = @user_id AND follows.followee_id = chirps.user_id))
+ORDER BY bookmarks.created_at DESC, bookmarks.chirp_id DESC
+LIMIT @row_limit;
+
+-- name: GetBookmarkedChirpIDs :many
+SELECT chirp_id FROM bookmarks
+WHERE user_id = @user_id AND chirp_id = ANY(@chirp_ids::uuid[]);
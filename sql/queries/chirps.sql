@@ -1,22 +1,57 @@
 -- name: CreateChirp :one
-INSERT INTO chirps (id, created_at, updated_at, body, user_id)
+INSERT INTO chirps (id, created_at, updated_at, body, user_id, parent_id, root_id, quote_of, fanned_out)
 VALUES (
     $1,
     $2,
     $3,
     $4,
-    $5
+    $5,
+    $6,
+    $7,
+    $8,
+    $9
 )
 RETURNING *;
 
--- name: GetAllChirps :many
+-- name: ListAllChirpsForBackfill :many
+-- Every chirp, whoever wrote it, for maintenance commands rather than feeds.
 SELECT * FROM chirps
-ORDER BY created_at ASC;
+WHERE (NOT @has_cursor::boolean OR (created_at, id) > (@cursor_created_at::timestamp, @cursor_id::uuid))
+ORDER BY created_at ASC, id ASC
+LIMIT @row_limit;
 
--- name: GetAllChirpsFromAuthor :many
+-- name: ListChirpsAsc :many
 SELECT * FROM chirps
-WHERE user_id = $1
-ORDER BY created_at ASC;
+WHERE (NOT @has_cursor::boolean OR (created_at, id) > (@cursor_created_at::timestamp, @cursor_id::uuid))
+AND NOT EXISTS (SELECT 1 FROM blocks WHERE blocks.blocker_id = chirps.user_id AND blocks.blocked_id = sqlc.narg('viewer_id'))
+AND NOT EXISTS (SELECT 1 FROM mutes WHERE mutes.muter_id = sqlc.narg('viewer_id') AND mutes.muted_id = chirps.user_id)
+AND (chirps.user_id = sqlc.narg('viewer_id')
+    OR NOT EXISTS (SELECT 1 FROM users authors WHERE authors.id = chirps.user_id AND authors.protected)
+    OR EXISTS (SELECT 1 FROM follows WHERE follows.follower_id = sqlc.narg('viewer_id') AND follows.followee_id = chirps.user_id))
+AND (sqlc.narg('list_id')::uuid IS NULL OR chirps.user_id IN (SELECT list_members.user_id FROM list_members WHERE list_members.list_id = sqlc.narg('list_id')))
+ORDER BY created_at ASC, id ASC
+LIMIT @row_limit;
+
+-- name: ListChirpsDesc :many
+SELECT * FROM chirps
+WHERE (NOT @has_cursor::boolean OR (created_at, id) < (@cursor_created_at::timestamp, @cursor_id::uuid))
+AND NOT EXISTS (SELECT 1 FROM blocks WHERE blocks.blocker_id = chirps.user_id AND blocks.blocked_id = sqlc.narg('viewer_id'))
+AND NOT EXISTS (SELECT 1 FROM mutes WHERE mutes.muter_id = sqlc.narg('viewer_id') AND mutes.muted_id = chirps.user_id)
+AND (chirps.user_id = sqlc.narg('viewer_id')
+    OR NOT EXISTS (SELECT 1 FROM users authors WHERE authors.id = chirps.user_id AND authors.protected)
+    OR EXISTS (SELECT 1 FROM follows WHERE follows.follower_id = sqlc.narg('viewer_id') AND follows.followee_id = chirps.user_id))
+AND (sqlc.narg('list_id')::uuid IS NULL OR chirps.user_id IN (SELECT list_members.user_id FROM list_members WHERE list_members.list_id = sqlc.narg('list_id')))
+ORDER BY created_at DESC, id DESC
+LIMIT @row_limit;
+
+-- name: GetVisibleChirpsByIDs :many
+SELECT * FROM chirps
+WHERE id = ANY(@ids::uuid[])
+AND NOT EXISTS (SELECT 1 FROM blocks WHERE blocks.blocker_id = chirps.user_id AND blocks.blocked_id = sqlc.narg('viewer_id'))
+AND NOT EXISTS (SELECT 1 FROM mutes WHERE mutes.muter_id = sqlc.narg('viewer_id') AND mutes.muted_id = chirps.user_id)
+AND (chirps.user_id = sqlc.narg('viewer_id')
+    OR NOT EXISTS (SELECT 1 FROM users authors WHERE authors.id = chirps.user_id AND authors.protected)
+    OR EXISTS (SELECT 1 FROM follows WHERE follows.follower_id = sqlc.narg('viewer_id') AND follows.followee_id = chirps.user_id));
 
 -- name: GetChirpByID :one
 SELECT * FROM chirps
@@ -24,4 +59,54 @@ WHERE id = $1;
 
 -- name: DeleteChirp :exec
 DELETE FROM chirps
-WHERE id = $1;
\ No newline at end of file
+WHERE id = $1;
+
+-- name: GetChirpForUpdate :one
+SELECT * FROM chirps
+WHERE id = $1
+FOR UPDATE;
+
+-- name: UpdateChirpBody :one
+UPDATE chirps
+SET body = $2, updated_at = $3, revision_count = revision_count + 1
+WHERE id = $1
+RETURNING *;
+
+
+-- name: GetChirpThread :many
+-- Walks down at most max_depth replies from the root and returns the
+-- row_limit earliest chirps, so a large conversation is never loaded whole.
+-- Replies whose parent was deleted start where the thread places them: under
+-- a placeholder for their parent, itself a reply to the root.
+WITH RECURSIVE thread (id, depth) AS (
+    SELECT starts.id, CASE WHEN starts.id = @root_id THEN 0 WHEN starts.parent_id = @root_id THEN 1 ELSE 2 END
+    FROM chirps starts
+    WHERE starts.root_id = @root_id
+    AND (starts.id = @root_id OR NOT EXISTS (SELECT 1 FROM chirps parents WHERE parents.id = starts.parent_id))
+    UNION ALL
+    SELECT replies.id, thread.depth + 1
+    FROM thread JOIN chirps replies ON replies.parent_id = thread.id
+    WHERE thread.depth < @max_depth::integer
+)
+SELECT chirps.* FROM chirps
+JOIN thread ON thread.id = chirps.id
+WHERE thread.depth <= @max_depth::integer
+AND (chirps.id = @chirp_id OR (
+    NOT EXISTS (SELECT 1 FROM blocks WHERE blocks.blocker_id = chirps.user_id AND blocks.blocked_id = sqlc.narg('viewer_id'))
+    AND NOT EXISTS (SELECT 1 FROM mutes WHERE mutes.muter_id = sqlc.narg('viewer_id') AND mutes.muted_id = chirps.user_id)
+    AND (chirps.user_id = sqlc.narg('viewer_id')
+        OR NOT EXISTS (SELECT 1 FROM users authors WHERE authors.id = chirps.user_id AND authors.protected)
+        OR EXISTS (SELECT 1 FROM follows WHERE follows.follower_id = sqlc.narg('viewer_id') AND follows.followee_id = chirps.user_id))
+))
+ORDER BY chirps.created_at ASC, chirps.id ASC
+LIMIT @row_limit;
+
+-- name: IncrementChirpLikeCount :exec
+UPDATE chirps
+SET like_count = like_count + 1
+WHERE id = $1;
+
+-- name: DecrementChirpLikeCount :exec
+UPDATE chirps
+SET like_count = like_count - 1
+WHERE id = $1;
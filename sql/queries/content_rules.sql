@@ -0,0 +1,54 @@
+-- name: CreateContentRule :one
+INSERT INTO content_rules (id, created_at, updated_at, position, name, kind, action, config, enabled)
+VALUES (
+    $1,
+    $2,
+    $3,
+    $4,
+    $5,
+    $6,
+    $7,
+    $8,
+    $9
+)
+RETURNING *;
+
+-- name: ListContentRules :many
+SELECT * FROM content_rules
+ORDER BY position ASC, created_at ASC;
+
+-- name: ListEnabledContentRules :many
+SELECT * FROM content_rules
+WHERE enabled
+ORDER BY position ASC, created_at ASC;
+
+-- name: UpdateContentRule :one
+UPDATE content_rules
+SET position = $2, name = $3, kind = $4, action = $5, config = $6, enabled = $7, updated_at = $8
+WHERE id = $1
+RETURNING *;
+
+-- name: DeleteContentRule :execrows
+DELETE FROM content_rules
+WHERE id = $1;
+
+-- name: CreateChirpFlag :exec
+INSERT INTO chirp_flags (id, created_at, chirp_id, rule_id, rule_name, reason)
+VALUES (
+    $1,
+    $2,
+    $3,
+    $4,
+    $5,
+    $6
+);
+
+-- name: ListChirpFlags :many
+SELECT * FROM chirp_flags
+WHERE (NOT @has_cursor::boolean OR (created_at, id) < (@cursor_created_at::timestamp, @cursor_id::uuid))
+ORDER BY created_at DESC, id DESC
+LIMIT @row_limit;
+
+-- name: DeleteChirpFlag :execrows
+DELETE FROM chirp_flags
+WHERE id = $1;
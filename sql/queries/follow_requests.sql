@@ -0,0 +1,19 @@
+-- name: CreateFollowRequest :execrows
+INSERT INTO follow_requests (requester_id, target_id, created_at)
+VALUES (
+    $1,
+    $2,
+    $3
+)
+ON CONFLICT (requester_id, target_id) DO NOTHING;
+
+-- name: DeleteFollowRequest :execrows
+DELETE FROM follow_requests
+WHERE requester_id = $1 AND target_id = $2;
+
+-- name: ListFollowRequests :many
+SELECT * FROM follow_requests
+WHERE target_id = @target_id
+AND (NOT @has_cursor::boolean OR (created_at, requester_id) < (@cursor_created_at::timestamp, @cursor_id::uuid))
+ORDER BY created_at DESC, requester_id DESC
+LIMIT @row_limit;
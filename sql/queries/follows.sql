@@ -0,0 +1,32 @@
+-- name: CreateFollow :execrows
+INSERT INTO follows (follower_id, followee_id, created_at)
+VALUES (
+    $1,
+    $2,
+    $3
+)
+ON CONFLICT (follower_id, followee_id) DO NOTHING;
+
+-- name: DeleteFollow :execrows
+DELETE FROM follows
+WHERE follower_id = $1 AND followee_id = $2;
+
+-- name: IsFollowing :one
+SELECT EXISTS (
+    SELECT 1 FROM follows
+    WHERE follower_id = $1 AND followee_id = $2
+);
+
+-- name: ListFollowers :many
+SELECT * FROM follows
+WHERE followee_id = @user_id
+AND (NOT @has_cursor::boolean OR (created_at, follower_id) < (@cursor_created_at::timestamp, @cursor_id::uuid))
+ORDER BY created_at DESC, follower_id DESC
+LIMIT @row_limit;
+
+-- name: ListFollowing :many
+SELECT * FROM follows
+WHERE follower_id = @user_id
+AND (NOT @has_cursor::boolean OR (created_at, followee_id) < (@cursor_created_at::timestamp, @cursor_id::uuid))
+ORDER BY created_at DESC, followee_id DESC
+LIMIT @row_limit;
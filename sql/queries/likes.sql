@@ -0,0 +1,24 @@
+-- name: CreateLike :execrows
+INSERT INTO likes (user_id, chirp_id, created_at)
+VALUES (
+    $1,
+    $2,
+    $3
+)
+ON CONFLICT (user_id, chirp_id) DO NOTHING;
+
+-- name: DeleteLike :execrows
+DELETE FROM likes
+WHERE user_id = $1 AND chirp_id = $2;
+
+-- name: ListChirpLikes :many
+SELECT sqlc.embed(likes), sqlc.embed(users) FROM likes
+JOIN users ON users.id = likes.user_id
+WHERE likes.chirp_id = @chirp_id
+AND (NOT @has_cursor::boolean OR (likes.created_at, likes.user_id) < (@cursor_created_at::timestamp, @cursor_id::uuid))
+ORDER BY likes.created_at DESC, likes.user_id DESC
+LIMIT @row_limit;
+
+-- name: GetLikedChirpIDs :many
+SELECT chirp_id FROM likes
+WHERE user_id = @user_id AND chirp_id = ANY(@chirp_ids::uuid[]);
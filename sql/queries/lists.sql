@@ -0,0 +1,66 @@
+-- name: CreateList :one
+INSERT INTO lists (id, created_at, updated_at, owner_id, name, description, private)
+VALUES (
+    $1,
+    $2,
+    $3,
+    $4,
+    $5,
+    $6,
+    $7
+)
+RETURNING *;
+
+-- name: GetListByID :one
+SELECT * FROM lists
+WHERE id = $1;
+
+-- name: ListUserLists :many
+SELECT * FROM lists
+WHERE owner_id = @owner_id
+AND (@include_private::boolean OR NOT private)
+AND (NOT @has_cursor::boolean OR (created_at, id) < (@cursor_created_at::timestamp, @cursor_id::uuid))
+ORDER BY created_at DESC, id DESC
+LIMIT @row_limit;
+
+-- name: UpdateList :one
+UPDATE lists
+SET name = $2, description = $3, private = $4, updated_at = $5
+WHERE id = $1
+RETURNING *;
+
+-- name: DeleteList :exec
+DELETE FROM lists
+WHERE id = $1;
+
+-- name: AddListMember :execrows
+INSERT INTO list_members (list_id, user_id, created_at)
+VALUES (
+    $1,
+    $2,
+    $3
+)
+ON CONFLICT (list_id, user_id) DO NOTHING;
+
+-- name: RemoveListMember :execrows
+DELETE FROM list_members
+WHERE list_id = $1 AND user_id = $2;
+
+-- name: IncrementListMemberCount :execrows
+-- Only counts the member while the list is below member_limit. The row lock
+-- the update takes makes concurrent adds check the limit one at a time.
+UPDATE lists
+SET member_count = member_count + 1
+WHERE id = @id AND member_count < @member_limit::integer;
+
+-- name: DecrementListMemberCount :exec
+UPDATE lists
+SET member_count = member_count - 1
+WHERE id = $1;
+
+-- name: ListListMembers :many
+SELECT * FROM list_members
+WHERE list_id = @list_id
+AND (NOT @has_cursor::boolean OR (created_at, user_id) < (@cursor_created_at::timestamp, @cursor_id::uuid))
+ORDER BY created_at DESC, user_id DESC
+LIMIT @row_limit;
@@ -0,0 +1,25 @@
+-- name: EnsureLoginThrottle :exec
+INSERT INTO login_throttles (kind, subject, failures, last_failure_at, locked_until)
+VALUES ($1, $2, 0, $3, NULL)
+ON CONFLICT (kind, subject) DO NOTHING;
+
+-- name: LockLoginThrottle :one
+-- Locks the row while a failure is added, so failures on several instances
+-- at once are all counted.
+SELECT * FROM login_throttles
+WHERE kind = $1 AND subject = $2
+FOR UPDATE;
+
+-- name: UpdateLoginThrottle :exec
+UPDATE login_throttles
+SET failures = $3, last_failure_at = $4, locked_until = $5
+WHERE kind = $1 AND subject = $2;
+
+-- name: DeleteLoginThrottle :exec
+DELETE FROM login_throttles
+WHERE kind = $1 AND subject = $2;
+
+-- name: DeleteStaleLoginThrottles :execrows
+DELETE FROM login_throttles
+WHERE last_failure_at < @stale_before::timestamp
+AND (locked_until IS NULL OR locked_until < @now::timestamp);
@@ -0,0 +1,40 @@
+-- name: CreateMedia :one
+INSERT INTO media (
+    id, created_at, user_id, content_type, byte_size, width, height,
+    storage_key, thumbnail_key, thumbnail_content_type, thumbnail_width, thumbnail_height, blurhash
+)
+VALUES (
+    $1,
+    $2,
+    $3,
+    $4,
+    $5,
+    $6,
+    $7,
+    $8,
+    $9,
+    $10,
+    $11,
+    $12,
+    $13
+)
+RETURNING *;
+
+-- name: GetMediaByID :one
+SELECT * FROM media
+WHERE id = $1;
+
+-- name: AttachMedia :execrows
+UPDATE media
+SET chirp_id = @chirp_id, position = @position, alt_text = @alt_text
+WHERE id = @id AND user_id = @user_id AND chirp_id IS NULL;
+
+-- name: ListChirpMedia :many
+SELECT * FROM media
+WHERE chirp_id = ANY(@chirp_ids::uuid[])
+ORDER BY chirp_id, position;
+
+-- name: DeleteUnattachedMedia :many
+DELETE FROM media
+WHERE chirp_id IS NULL AND created_at < @created_before
+RETURNING *;
@@ -0,0 +1,38 @@
+-- name: CreatePersonalAccessToken :one
+INSERT INTO personal_access_tokens (id, created_at, updated_at, user_id, name, token_hash, scopes, expires_at)
+VALUES (
+    $1,
+    $2,
+    $3,
+    $4,
+    $5,
+    $6,
+    $7,
+    $8
+)
+RETURNING *;
+
+-- name: GetPersonalAccessTokenByHash :one
+SELECT * FROM personal_access_tokens
+WHERE token_hash = $1;
+
+-- name: ListUserPersonalAccessTokens :many
+SELECT * FROM personal_access_tokens
+WHERE user_id = $1
+ORDER BY created_at DESC, id DESC;
+
+-- name: CountUserPersonalAccessTokens :one
+SELECT count(*) FROM personal_access_tokens
+WHERE user_id = $1;
+
+-- name: DeletePersonalAccessToken :execrows
+DELETE FROM personal_access_tokens
+WHERE id = @id AND user_id = @user_id;
+
+-- name: TouchPersonalAccessToken :exec
+-- Records that a token was used, at most once a minute so busy scripts don't
+-- write on every request.
+UPDATE personal_access_tokens
+SET last_used_at = @now::timestamp
+WHERE id = @id
+AND (last_used_at IS NULL OR last_used_at < @now::timestamp - interval '1 minute');
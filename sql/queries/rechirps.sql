@@ -0,0 +1,59 @@
+-- name: CreateRechirp :exec
+INSERT INTO rechirps (id, created_at, user_id, chirp_id)
+VALUES (
+    $1,
+    $2,
+    $3,
+    $4
+)
+ON CONFLICT (user_id, chirp_id) DO NOTHING;
+
+-- name: DeleteRechirp :exec
+DELETE FROM rechirps
+WHERE user_id = $1 AND chirp_id = $2;
+
+-- name: ListAuthorTimelineAsc :many
+SELECT sqlc.embed(chirps), entries.entry_id, entries.entry_at, entries.rechirped
+FROM (
+    SELECT id AS entry_id, created_at AS entry_at, id AS chirp_id, FALSE AS rechirped
+    FROM chirps WHERE chirps.user_id = @author_id
+    UNION ALL
+    SELECT id, created_at, chirp_id, TRUE
+    FROM rechirps WHERE rechirps.user_id = @author_id
+    AND NOT EXISTS (SELECT 1 FROM blocks WHERE blocks.blocker_id = rechirps.user_id AND blocks.blocked_id = sqlc.narg('viewer_id'))
+    AND (rechirps.user_id = sqlc.narg('viewer_id')
+        OR NOT EXISTS (SELECT 1 FROM users rechirpers WHERE rechirpers.id = rechirps.user_id AND rechirpers.protected)
+        OR EXISTS (SELECT 1 FROM follows WHERE follows.follower_id = sqlc.narg('viewer_id') AND follows.followee_id = rechirps.user_id))
+) entries
+JOIN chirps ON chirps.id = entries.chirp_id
+WHERE (NOT @has_cursor::boolean OR (entries.entry_at, entries.entry_id) > (@cursor_created_at::timestamp, @cursor_id::uuid))
+AND NOT EXISTS (SELECT 1 FROM blocks WHERE blocks.blocker_id = chirps.user_id AND blocks.blocked_id = sqlc.narg('viewer_id'))
+AND NOT EXISTS (SELECT 1 FROM mutes WHERE mutes.muter_id = sqlc.narg('viewer_id') AND mutes.muted_id = chirps.user_id)
+AND (chirps.user_id = sqlc.narg('viewer_id')
+    OR NOT EXISTS (SELECT 1 FROM users authors WHERE authors.id = chirps.user_id AND authors.protected)
+    OR EXISTS (SELECT 1 FROM follows WHERE follows.follower_id = sqlc.narg('viewer_id') AND follows.followee_id = chirps.user_id))
+ORDER BY entries.entry_at ASC, entries.entry_id ASC
+LIMIT @row_limit;
+
+-- name: ListAuthorTimelineDesc :many
+SELECT sqlc.embed(chirps), entries.entry_id, entries.entry_at, entries.rechirped
+FROM (
+    SELECT id AS entry_id, created_at AS entry_at, id AS chirp_id, FALSE AS rechirped
+    FROM chirps WHERE chirps.user_id = @author_id
+    UNION ALL
+    SELECT id, created_at, chirp_id, TRUE
+    FROM rechirps WHERE rechirps.user_id = @author_id
+    AND NOT EXISTS (SELECT 1 FROM blocks WHERE blocks.blocker_id = rechirps.user_id AND blocks.blocked_id = sqlc.narg('viewer_id'))
+    AND (rechirps.user_id = sqlc.narg('viewer_id')
+        OR NOT EXISTS (SELECT 1 FROM users rechirpers WHERE rechirpers.id = rechirps.user_id AND rechirpers.protected)
+        OR EXISTS (SELECT 1 FROM follows WHERE follows.follower_id = sqlc.narg('viewer_id') AND follows.followee_id = rechirps.user_id))
+) entries
+JOIN chirps ON chirps.id = entries.chirp_id
+WHERE (NOT @has_cursor::boolean OR (entries.entry_at, entries.entry_id) < (@cursor_created_at::timestamp, @cursor_id::uuid))
+AND NOT EXISTS (SELECT 1 FROM blocks WHERE blocks.blocker_id = chirps.user_id AND blocks.blocked_id = sqlc.narg('viewer_id'))
+AND NOT EXISTS (SELECT 1 FROM mutes WHERE mutes.muter_id = sqlc.narg('viewer_id') AND mutes.muted_id = chirps.user_id)
+AND (chirps.user_id = sqlc.narg('viewer_id')
+    OR NOT EXISTS (SELECT 1 FROM users authors WHERE authors.id = chirps.user_id AND authors.protected)
+    OR EXISTS (SELECT 1 FROM follows WHERE follows.follower_id = sqlc.narg('viewer_id') AND follows.followee_id = chirps.user_id))
+ORDER BY entries.entry_at DESC, entries.entry_id DESC
+LIMIT @row_limit;
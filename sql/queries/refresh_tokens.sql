@@ -1,21 +1,78 @@
 -- name: StoreRefreshToken :one
-INSERT INTO refresh_tokens (token, created_at, updated_at, user_id, expires_at, revoked_at)
+INSERT INTO refresh_tokens (token_hash, created_at, updated_at, user_id, expires_at, revoked_at, family_id, device_label, user_agent, ip_address, last_used_at)
 VALUES (
     $1,
     $2,
     $3,
     $4,
     $5,
-    NULL
+    NULL,
+    $6,
+    $7,
+    $8,
+    $9,
+    $10
 )
 RETURNING *;
 
 -- name: RevokeRefreshToken :one
 UPDATE refresh_tokens 
-SET revoked_at = $2, updated_at = $2 WHERE token = $1
+SET revoked_at = $2, updated_at = $2 WHERE token_hash = $1
 RETURNING *; 
 
 -- name: GetRefreshToken :one
 SELECT * FROM refresh_tokens
-WHERE token = $1;
+WHERE token_hash = $1;
 
+-- name: IsSessionRevoked :one
+-- Revoking a session revokes every token in its family.
+SELECT EXISTS (
+    SELECT 1 FROM refresh_tokens
+    WHERE family_id = $1 AND revoked_at IS NOT NULL
+);
+
+-- name: RotateRefreshToken :one
+-- Retires a token that is still usable. Of two refreshes racing with the
+-- same token, only one gets a row back.
+UPDATE refresh_tokens
+SET rotated_at = @now::timestamp, updated_at = @now::timestamp
+WHERE token_hash = @token_hash
+AND rotated_at IS NULL
+AND revoked_at IS NULL
+AND expires_at > @now::timestamp
+RETURNING *;
+
+-- name: RevokeRefreshTokenFamily :exec
+UPDATE refresh_tokens
+SET revoked_at = @revoked_at, updated_at = @revoked_at
+WHERE family_id = @family_id
+AND revoked_at IS NULL;
+
+-- name: ListUserSessions :many
+-- Lists the live token of each of a user's sessions, along with when the
+-- session started.
+SELECT sqlc.embed(refresh_tokens), (
+    SELECT min(family.created_at) FROM refresh_tokens AS family
+    WHERE family.family_id = refresh_tokens.family_id
+)::timestamp AS session_created_at
+FROM refresh_tokens
+WHERE refresh_tokens.user_id = @user_id
+AND refresh_tokens.rotated_at IS NULL
+AND refresh_tokens.revoked_at IS NULL
+AND refresh_tokens.expires_at > @now::timestamp
+ORDER BY refresh_tokens.last_used_at DESC;
+
+-- name: RevokeUserSession :execrows
+UPDATE refresh_tokens
+SET revoked_at = @revoked_at, updated_at = @revoked_at
+WHERE family_id = @family_id
+AND user_id = @user_id
+AND revoked_at IS NULL;
+
+-- name: RevokeUserSessions :execrows
+-- Revokes all of a user's sessions, except except_family_id if given.
+UPDATE refresh_tokens
+SET revoked_at = @revoked_at, updated_at = @revoked_at
+WHERE user_id = @user_id
+AND revoked_at IS NULL
+AND (sqlc.narg('except_family_id')::uuid IS NULL OR family_id <> sqlc.narg('except_family_id')::uuid);
@@ -0,0 +1,21 @@
+-- name: SearchChirps :many
+SELECT sqlc.embed(chirps),
+    ts_rank(chirps.search_vector, websearch_to_tsquery('english', @query::text))::real AS rank,
+    ts_headline(
+        'english',
+        replace(replace(replace(chirps.body, '&', '&amp;'), '<', '&lt;'), '>', '&gt;'),
+        websearch_to_tsquery('english', @query::text),
+        'StartSel=<mark>, StopSel=</mark>, MaxWords=35, MinWords=15'
+    )::text AS snippet
+FROM chirps
+WHERE (@query::text = '' OR chirps.search_vector @@ websearch_to_tsquery('english', @query::text))
+AND (sqlc.narg('author_id')::uuid IS NULL OR chirps.user_id = sqlc.narg('author_id'))
+AND (sqlc.narg('since')::timestamp IS NULL OR chirps.created_at >= sqlc.narg('since'))
+AND (sqlc.narg('until')::timestamp IS NULL OR chirps.created_at < sqlc.narg('until'))
+AND NOT EXISTS (SELECT 1 FROM blocks WHERE blocks.blocker_id = chirps.user_id AND blocks.blocked_id = sqlc.narg('viewer_id'))
+AND NOT EXISTS (SELECT 1 FROM mutes WHERE mutes.muter_id = sqlc.narg('viewer_id') AND mutes.muted_id = chirps.user_id)
+AND (chirps.user_id = sqlc.narg('viewer_id')
+    OR NOT EXISTS (SELECT 1 FROM users authors WHERE authors.id = chirps.user_id AND authors.protected)
+    OR EXISTS (SELECT 1 FROM follows WHERE follows.follower_id = sqlc.narg('viewer_id') AND follows.followee_id = chirps.user_id))
+ORDER BY rank DESC, chirps.created_at DESC, chirps.id DESC
+LIMIT @row_limit OFFSET @row_offset;
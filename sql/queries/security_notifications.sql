@@ -0,0 +1,18 @@
+-- name: CreateSecurityNotification :one
+INSERT INTO security_notifications (id, created_at, user_id, kind, message, ip_address)
+VALUES (
+    $1,
+    $2,
+    $3,
+    $4,
+    $5,
+    $6
+)
+RETURNING *;
+
+-- name: ListSecurityNotifications :many
+SELECT * FROM security_notifications
+WHERE user_id = @user_id
+AND (NOT @has_cursor::boolean OR (created_at, id) < (@cursor_created_at::timestamp, @cursor_id::uuid))
+ORDER BY created_at DESC, id DESC
+LIMIT @row_limit;
@@ -0,0 +1,37 @@
+-- name: UpsertTag :one
+INSERT INTO tags (id, name)
+VALUES (
+    $1,
+    $2
+)
+ON CONFLICT (name) DO UPDATE SET name = EXCLUDED.name
+RETURNING *;
+
+-- name: GetTagByName :one
+SELECT * FROM tags
+WHERE name = $1;
+
+-- name: CreateChirpTag :exec
+INSERT INTO chirp_tags (chirp_id, tag_id)
+VALUES (
+    $1,
+    $2
+)
+ON CONFLICT (chirp_id, tag_id) DO NOTHING;
+
+-- name: DeleteChirpTags :exec
+DELETE FROM chirp_tags
+WHERE chirp_id = $1;
+
+-- name: ListTagChirps :many
+SELECT chirps.* FROM chirps
+JOIN chirp_tags ON chirp_tags.chirp_id = chirps.id
+WHERE chirp_tags.tag_id = @tag_id
+AND (NOT @has_cursor::boolean OR (chirps.created_at, chirps.id) < (@cursor_created_at::timestamp, @cursor_id::uuid))
+AND NOT EXISTS (SELECT 1 FROM blocks WHERE blocks.blocker_id = chirps.user_id AND blocks.blocked_id = sqlc.narg('viewer_id'))
+AND NOT EXISTS (SELECT 1 FROM mutes WHERE mutes.muter_id = sqlc.narg('viewer_id') AND mutes.muted_id = chirps.user_id)
+AND (chirps.user_id = sqlc.narg('viewer_id')
+    OR NOT EXISTS (SELECT 1 FROM users authors WHERE authors.id = chirps.user_id AND authors.protected)
+    OR EXISTS (SELECT 1 FROM follows WHERE follows.follower_id = sqlc.narg('viewer_id') AND follows.followee_id = chirps.user_id))
+ORDER BY chirps.created_at DESC, chirps.id DESC
+LIMIT @row_limit;
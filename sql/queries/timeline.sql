@@ -0,0 +1,50 @@
+-- name: FanOutChirp :exec
+INSERT INTO timeline_entries (user_id, chirp_id, created_at)
+SELECT follows.follower_id, @chirp_id::uuid, @created_at::timestamp
+FROM follows
+WHERE follows.followee_id = @author_id
+ON CONFLICT (user_id, chirp_id) DO NOTHING;
+
+-- name: BackfillTimeline :exec
+INSERT INTO timeline_entries (user_id, chirp_id, created_at)
+SELECT @user_id::uuid, chirps.id, chirps.created_at
+FROM chirps
+WHERE chirps.user_id = @author_id
+AND chirps.fanned_out
+ORDER BY chirps.created_at DESC
+LIMIT @row_limit
+ON CONFLICT (user_id, chirp_id) DO NOTHING;
+
+-- name: DeleteTimelineEntriesByAuthor :exec
+DELETE FROM timeline_entries
+WHERE timeline_entries.user_id = @user_id
+AND timeline_entries.chirp_id IN (SELECT chirps.id FROM chirps WHERE chirps.user_id = @author_id);
+
+-- name: ListInboxChirps :many
+SELECT chirps.* FROM timeline_entries
+JOIN chirps ON chirps.id = timeline_entries.chirp_id
+WHERE timeline_entries.user_id = @user_id
+AND (NOT @has_cursor::boolean OR (timeline_entries.created_at, timeline_entries.chirp_id) < (@cursor_created_at::timestamp, @cursor_id::uuid))
+AND NOT EXISTS (SELECT 1 FROM blocks WHERE blocks.blocker_id = chirps.user_id AND blocks.blocked_id = sqlc.narg('viewer_id'))
+AND NOT EXISTS (SELECT 1 FROM mutes WHERE mutes.muter_id = sqlc.narg('viewer_id') AND mutes.muted_id = chirps.user_id)
+AND (chirps.user_id = sqlc.narg('viewer_id')
+    OR NOT EXISTS (SELECT 1 FROM users authors WHERE authors.id = chirps.user_id AND authors.protected)
+    OR EXISTS (SELECT 1 FROM follows WHERE follows.follower_id = sqlc.narg('viewer_id') AND follows.followee_id = chirps.user_id))
+ORDER BY timeline_entries.created_at DESC, timeline_entries.chirp_id DESC
+LIMIT @row_limit;
+
+-- name: ListPulledChirps :many
+-- Lists the chirps of the accounts user_id follows that weren't fanned out,
+-- which the home timeline reads here instead of from the inbox.
+SELECT chirps.* FROM chirps
+JOIN follows ON follows.followee_id = chirps.user_id
+WHERE follows.follower_id = @user_id
+AND NOT chirps.fanned_out
+AND (NOT @has_cursor::boolean OR (chirps.created_at, chirps.id) < (@cursor_created_at::timestamp, @cursor_id::uuid))
+AND NOT EXISTS (SELECT 1 FROM blocks WHERE blocks.blocker_id = chirps.user_id AND blocks.blocked_id = sqlc.narg('viewer_id'))
+AND NOT EXISTS (SELECT 1 FROM mutes WHERE mutes.muter_id = sqlc.narg('viewer_id') AND mutes.muted_id = chirps.user_id)
+AND (chirps.user_id = sqlc.narg('viewer_id')
+    OR NOT EXISTS (SELECT 1 FROM users authors WHERE authors.id = chirps.user_id AND authors.protected)
+    OR EXISTS (SELECT 1 FROM follows WHERE follows.follower_id = sqlc.narg('viewer_id') AND follows.followee_id = chirps.user_id))
+ORDER BY chirps.created_at DESC, chirps.id DESC
+LIMIT @row_limit;
@@ -16,6 +16,10 @@ DELETE FROM users;
 SELECT * FROM users
 WHERE id = $1;
 
+-- name: GetUserByHandle :one
+SELECT * FROM users
+WHERE lower(handle) = lower(@handle::text);
+
 -- name: GetUserByEmail :one
 SELECT * FROM users
 WHERE email = $1;
@@ -25,7 +29,36 @@ UPDATE users
 SET email = $2, hashed_password = $3, updated_at = NOW() WHERE id = $1
 RETURNING *; 
 
+-- name: UpdateUserProfile :one
+UPDATE users
+SET handle = COALESCE(sqlc.narg('handle'), handle),
+    handle_skeleton = COALESCE(sqlc.narg('handle_skeleton'), handle_skeleton),
+    display_name = COALESCE(sqlc.narg('display_name'), display_name),
+    bio = COALESCE(sqlc.narg('bio'), bio),
+    location = COALESCE(sqlc.narg('location'), location),
+    website = COALESCE(sqlc.narg('website'), website),
+    updated_at = NOW()
+WHERE id = @id
+RETURNING *;
+
 -- name: SetUserSubscription :one
 UPDATE users
 SET is_chirpy_red = $2 WHERE id = $1
-RETURNING *;
\ No newline at end of file
+RETURNING *;
+
+-- name: SetUserProtected :one
+UPDATE users
+SET protected = $2, updated_at = NOW() WHERE id = $1
+RETURNING *;
+
+-- name: IncrementFollowCounts :exec
+UPDATE users
+SET follower_count = follower_count + CASE WHEN id = @followee_id::uuid THEN 1 ELSE 0 END,
+    following_count = following_count + CASE WHEN id = @follower_id::uuid THEN 1 ELSE 0 END
+WHERE id IN (@follower_id, @followee_id);
+
+-- name: DecrementFollowCounts :exec
+UPDATE users
+SET follower_count = follower_count - CASE WHEN id = @followee_id::uuid THEN 1 ELSE 0 END,
+    following_count = following_count - CASE WHEN id = @follower_id::uuid THEN 1 ELSE 0 END
+WHERE id IN (@follower_id, @followee_id);
@@ -0,0 +1,20 @@
+-- +goose Up
+-- parent_id deliberately has no foreign key: deleting a chirp must leave its
+-- replies in place so threads can show a placeholder for the missing parent.
+ALTER TABLE chirps
+ADD parent_id UUID,
+ADD root_id UUID;
+
+UPDATE chirps SET root_id = id;
+
+ALTER TABLE chirps
+ALTER root_id SET NOT NULL;
+
+CREATE INDEX chirps_root_id_idx ON chirps (root_id, created_at);
+
+-- +goose Down
+DROP INDEX chirps_root_id_idx;
+
+ALTER TABLE chirps
+DROP parent_id,
+DROP root_id;
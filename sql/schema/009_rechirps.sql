@@ -0,0 +1,20 @@
+-- +goose Up
+-- Like parent_id, quote_of has no foreign key so quotes survive the quoted
+-- chirp being deleted.
+ALTER TABLE chirps
+ADD quote_of UUID;
+
+CREATE TABLE rechirps (
+    id UUID PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL,
+    user_id UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    chirp_id UUID NOT NULL REFERENCES chirps (id) ON DELETE CASCADE,
+    UNIQUE (user_id, chirp_id)
+);
+CREATE INDEX rechirps_user_id_created_at_id_idx ON rechirps (user_id, created_at, id);
+
+-- +goose Down
+DROP TABLE rechirps;
+
+ALTER TABLE chirps
+DROP quote_of;
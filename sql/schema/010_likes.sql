@@ -0,0 +1,17 @@
+-- +goose Up
+ALTER TABLE chirps
+ADD like_count INTEGER NOT NULL DEFAULT 0;
+
+CREATE TABLE likes (
+    user_id UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    chirp_id UUID NOT NULL REFERENCES chirps (id) ON DELETE CASCADE,
+    created_at TIMESTAMP NOT NULL,
+    PRIMARY KEY (user_id, chirp_id)
+);
+CREATE INDEX likes_chirp_id_created_at_idx ON likes (chirp_id, created_at, user_id);
+
+-- +goose Down
+DROP TABLE likes;
+
+ALTER TABLE chirps
+DROP like_count;
@@ -0,0 +1,16 @@
+-- +goose Up
+CREATE TABLE tags (
+    id UUID PRIMARY KEY,
+    name TEXT UNIQUE NOT NULL
+);
+
+CREATE TABLE chirp_tags (
+    chirp_id UUID NOT NULL REFERENCES chirps (id) ON DELETE CASCADE,
+    tag_id UUID NOT NULL REFERENCES tags (id) ON DELETE CASCADE,
+    PRIMARY KEY (chirp_id, tag_id)
+);
+CREATE INDEX chirp_tags_tag_id_idx ON chirp_tags (tag_id);
+
+-- +goose Down
+DROP TABLE chirp_tags;
+DROP TABLE tags;
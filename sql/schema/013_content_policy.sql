@@ -0,0 +1,32 @@
+-- +goose Up
+ALTER TABLE users
+ADD is_admin BOOLEAN NOT NULL DEFAULT FALSE;
+
+CREATE TABLE content_rules (
+    id UUID PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL,
+    position INTEGER NOT NULL,
+    name TEXT NOT NULL,
+    kind TEXT NOT NULL,
+    action TEXT NOT NULL,
+    config JSONB NOT NULL DEFAULT '{}',
+    enabled BOOLEAN NOT NULL DEFAULT TRUE
+);
+
+CREATE TABLE chirp_flags (
+    id UUID PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL,
+    chirp_id UUID NOT NULL REFERENCES chirps (id) ON DELETE CASCADE,
+    rule_id UUID REFERENCES content_rules (id) ON DELETE SET NULL,
+    rule_name TEXT NOT NULL,
+    reason TEXT NOT NULL
+);
+CREATE INDEX chirp_flags_created_at_id_idx ON chirp_flags (created_at, id);
+
+-- +goose Down
+DROP TABLE chirp_flags;
+DROP TABLE content_rules;
+
+ALTER TABLE users
+DROP is_admin;
@@ -0,0 +1,21 @@
+-- +goose Up
+ALTER TABLE users
+ADD follower_count INTEGER NOT NULL DEFAULT 0,
+ADD following_count INTEGER NOT NULL DEFAULT 0;
+
+CREATE TABLE follows (
+    follower_id UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    followee_id UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    created_at TIMESTAMP NOT NULL,
+    PRIMARY KEY (follower_id, followee_id),
+    CHECK (follower_id <> followee_id)
+);
+CREATE INDEX follows_followee_id_created_at_idx ON follows (followee_id, created_at, follower_id);
+CREATE INDEX follows_follower_id_created_at_idx ON follows (follower_id, created_at, followee_id);
+
+-- +goose Down
+DROP TABLE follows;
+
+ALTER TABLE users
+DROP follower_count,
+DROP following_count;
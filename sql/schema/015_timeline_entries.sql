@@ -0,0 +1,17 @@
+-- +goose Up
+CREATE TABLE timeline_entries (
+    user_id UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    chirp_id UUID NOT NULL REFERENCES chirps (id) ON DELETE CASCADE,
+    created_at TIMESTAMP NOT NULL,
+    PRIMARY KEY (user_id, chirp_id)
+);
+CREATE INDEX timeline_entries_user_id_created_at_idx ON timeline_entries (user_id, created_at DESC, chirp_id DESC);
+
+-- Existing follows predate fan-out, so seed their inboxes
+INSERT INTO timeline_entries (user_id, chirp_id, created_at)
+SELECT follows.follower_id, chirps.id, chirps.created_at
+FROM follows
+JOIN chirps ON chirps.user_id = follows.followee_id;
+
+-- +goose Down
+DROP TABLE timeline_entries;
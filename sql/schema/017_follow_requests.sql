@@ -0,0 +1,18 @@
+-- +goose Up
+ALTER TABLE users
+ADD protected BOOLEAN NOT NULL DEFAULT FALSE;
+
+CREATE TABLE follow_requests (
+    requester_id UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    target_id UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    created_at TIMESTAMP NOT NULL,
+    PRIMARY KEY (requester_id, target_id),
+    CHECK (requester_id <> target_id)
+);
+CREATE INDEX follow_requests_target_id_created_at_idx ON follow_requests (target_id, created_at, requester_id);
+
+-- +goose Down
+DROP TABLE follow_requests;
+
+ALTER TABLE users
+DROP protected;
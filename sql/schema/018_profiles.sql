@@ -0,0 +1,23 @@
+-- +goose Up
+ALTER TABLE users
+ADD handle TEXT,
+ADD handle_skeleton TEXT,
+ADD display_name TEXT NOT NULL DEFAULT '',
+ADD bio TEXT NOT NULL DEFAULT '',
+ADD location TEXT NOT NULL DEFAULT '',
+ADD website TEXT NOT NULL DEFAULT '';
+
+CREATE UNIQUE INDEX users_handle_lower_idx ON users (lower(handle));
+CREATE UNIQUE INDEX users_handle_skeleton_idx ON users (handle_skeleton);
+
+-- +goose Down
+DROP INDEX users_handle_skeleton_idx;
+DROP INDEX users_handle_lower_idx;
+
+ALTER TABLE users
+DROP handle,
+DROP handle_skeleton,
+DROP display_name,
+DROP bio,
+DROP location,
+DROP website;
@@ -0,0 +1,20 @@
+-- +goose Up
+-- Deleting a chirp releases its media, which is then garbage collected
+ALTER TABLE media
+ADD blurhash TEXT NOT NULL DEFAULT '',
+ADD chirp_id UUID REFERENCES chirps (id) ON DELETE SET NULL,
+ADD position INTEGER NOT NULL DEFAULT 0,
+ADD alt_text TEXT NOT NULL DEFAULT '';
+
+CREATE INDEX media_chirp_id_idx ON media (chirp_id, position);
+CREATE INDEX media_unattached_created_at_idx ON media (created_at) WHERE chirp_id IS NULL;
+
+-- +goose Down
+DROP INDEX media_unattached_created_at_idx;
+DROP INDEX media_chirp_id_idx;
+
+ALTER TABLE media
+DROP blurhash,
+DROP chirp_id,
+DROP position,
+DROP alt_text;
@@ -0,0 +1,25 @@
+-- +goose Up
+CREATE TABLE lists (
+    id UUID PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL,
+    owner_id UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    name TEXT NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    private BOOLEAN NOT NULL DEFAULT FALSE,
+    member_count INTEGER NOT NULL DEFAULT 0
+);
+CREATE INDEX lists_owner_id_created_at_idx ON lists (owner_id, created_at DESC, id DESC);
+
+CREATE TABLE list_members (
+    list_id UUID NOT NULL REFERENCES lists (id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    created_at TIMESTAMP NOT NULL,
+    PRIMARY KEY (list_id, user_id)
+);
+CREATE INDEX list_members_list_id_created_at_idx ON list_members (list_id, created_at DESC, user_id DESC);
+CREATE INDEX list_members_user_id_idx ON list_members (user_id);
+
+-- +goose Down
+DROP TABLE list_members;
+DROP TABLE lists;
@@ -0,0 +1,21 @@
+-- +goose Up
+-- Every refresh hands out a new token in the same family and marks the old
+-- one rotated. A rotated token coming back means it was copied, and the whole
+-- family is revoked.
+ALTER TABLE refresh_tokens
+ADD family_id UUID,
+ADD rotated_at TIMESTAMP;
+
+-- Tokens issued before rotation each start a family of their own
+UPDATE refresh_tokens SET family_id = md5(token)::uuid;
+
+ALTER TABLE refresh_tokens ALTER family_id SET NOT NULL;
+
+CREATE INDEX refresh_tokens_family_id_idx ON refresh_tokens (family_id);
+
+-- +goose Down
+DROP INDEX refresh_tokens_family_id_idx;
+
+ALTER TABLE refresh_tokens
+DROP family_id,
+DROP rotated_at;
@@ -0,0 +1,13 @@
+-- +goose Up
+-- Refresh tokens are only kept as the hex SHA-256 digest of the token, so a
+-- copy of the database can't be used to log in. Existing tokens are hashed
+-- in place and keep working.
+ALTER TABLE refresh_tokens RENAME token TO token_hash;
+
+UPDATE refresh_tokens SET token_hash = encode(sha256(convert_to(token_hash, 'UTF8')), 'hex');
+
+-- +goose Down
+-- The hashed tokens can't be turned back into tokens, so their sessions end
+DELETE FROM refresh_tokens;
+
+ALTER TABLE refresh_tokens RENAME token_hash TO token;
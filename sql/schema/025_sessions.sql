@@ -0,0 +1,25 @@
+-- +goose Up
+-- A session is a family of refresh tokens. Its live token records the device
+-- it was last used from; the device label given at login is carried over on
+-- every refresh.
+ALTER TABLE refresh_tokens
+ADD device_label TEXT NOT NULL DEFAULT '',
+ADD user_agent TEXT NOT NULL DEFAULT '',
+ADD ip_address TEXT NOT NULL DEFAULT '',
+ADD last_used_at TIMESTAMP;
+
+UPDATE refresh_tokens SET last_used_at = created_at;
+
+ALTER TABLE refresh_tokens ALTER last_used_at SET NOT NULL;
+
+CREATE INDEX refresh_tokens_live_user_id_idx ON refresh_tokens (user_id)
+WHERE rotated_at IS NULL AND revoked_at IS NULL;
+
+-- +goose Down
+DROP INDEX refresh_tokens_live_user_id_idx;
+
+ALTER TABLE refresh_tokens
+DROP device_label,
+DROP user_agent,
+DROP ip_address,
+DROP last_used_at;
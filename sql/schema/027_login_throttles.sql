@@ -0,0 +1,27 @@
+-- +goose Up
+-- Failed logins, counted per account and per address, so every instance sees
+-- the same backoff and lockouts survive restarts. Accounts are keyed by the
+-- email tried, whether or not a user has it.
+CREATE TABLE login_throttles (
+    kind TEXT NOT NULL,
+    subject TEXT NOT NULL,
+    failures INTEGER NOT NULL DEFAULT 0,
+    last_failure_at TIMESTAMP NOT NULL,
+    locked_until TIMESTAMP,
+    PRIMARY KEY (kind, subject)
+);
+CREATE INDEX login_throttles_last_failure_at_idx ON login_throttles (last_failure_at);
+
+CREATE TABLE security_notifications (
+    id UUID PRIMARY KEY,
+    created_at TIMESTAMP NOT NULL,
+    user_id UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    kind TEXT NOT NULL,
+    message TEXT NOT NULL,
+    ip_address TEXT NOT NULL DEFAULT ''
+);
+CREATE INDEX security_notifications_user_id_created_at_idx ON security_notifications (user_id, created_at DESC, id DESC);
+
+-- +goose Down
+DROP TABLE security_notifications;
+DROP TABLE login_throttles;
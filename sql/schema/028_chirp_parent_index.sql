@@ -0,0 +1,6 @@
+-- +goose Up
+-- Threads are walked down from the root one level of replies at a time.
+CREATE INDEX chirps_parent_id_idx ON chirps (parent_id);
+
+-- +goose Down
+DROP INDEX chirps_parent_id_idx;
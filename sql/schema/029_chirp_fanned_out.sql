@@ -0,0 +1,20 @@
+-- +goose Up
+-- Whether a chirp was copied into its author's followers' inboxes when it
+-- was posted. Chirps that weren't are merged into timelines when read, even
+-- if their author has since dropped below the fan-out limit. Existing chirps
+-- are judged by the default limit.
+ALTER TABLE chirps
+ADD COLUMN fanned_out BOOLEAN NOT NULL DEFAULT true;
+
+UPDATE chirps SET fanned_out = false
+FROM users
+WHERE users.id = chirps.user_id
+AND users.follower_count >= 10000;
+
+CREATE INDEX chirps_pulled_idx ON chirps (user_id, created_at, id) WHERE NOT fanned_out;
+
+-- +goose Down
+DROP INDEX chirps_pulled_idx;
+
+ALTER TABLE chirps
+DROP COLUMN fanned_out;
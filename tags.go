@@ -0,0 +1,61 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/jthughes/chirpynetwork/internal/database"
+	"github.com/jthughes/chirpynetwork/internal/hashtags"
+	"github.com/jthughes/chirpynetwork/internal/pagination"
+)
+
+func (cfg *apiConfig) handlerGetTagChirps(w http.ResponseWriter, r *http.Request) {
+
+	page, err := pagination.ParseRequest(r.URL.Query())
+	if err != nil {
+		ResponseError(w, err, "Invalid pagination parameters", http.StatusBadRequest)
+		return
+	}
+
+	// A tag nobody has used yet simply has an empty timeline
+	viewer := cfg.optionalViewer(r)
+	out := []Chirp{}
+	tag, err := cfg.db.GetTagByName(context.Background(), hashtags.Normalize(r.PathValue("tag")))
+	if errors.Is(err, sql.ErrNoRows) {
+		data, err := json.Marshal(out)
+		SetJSONResponse(w, http.StatusOK, data, err)
+		return
+	}
+	if err != nil {
+		ResponseError(w, err, "Error retrieving tag", http.StatusInternalServerError)
+		return
+	}
+
+	// Fetch one extra row to find out whether there is a next page
+	dbChirps, err := cfg.db.ListTagChirps(context.Background(), database.ListTagChirpsParams{
+		TagID:           tag.ID,
+		HasCursor:       page.HasCursor,
+		CursorCreatedAt: page.Cursor.CreatedAt,
+		CursorID:        page.Cursor.ID,
+		ViewerID:        viewer,
+		RowLimit:        int32(page.Limit + 1),
+	})
+	if err != nil {
+		ResponseError(w, err, "Error retrieving chirps", http.StatusInternalServerError)
+		return
+	}
+	dbChirps, next := pagination.Trim(dbChirps, page.Limit, chirpCursor)
+
+	out, err = cfg.buildChirps(context.Background(), dbChirps, viewer)
+	if err != nil {
+		ResponseError(w, err, "Error retrieving chirp details", http.StatusInternalServerError)
+		return
+	}
+
+	SetNextCursor(w, r, next)
+	data, err := json.Marshal(out)
+	SetJSONResponse(w, http.StatusOK, data, err)
+}
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/google/uuid"
+	"github.com/jthughes/chirpynetwork/internal/database"
+)
+
+// Limits on how much of a conversation a thread shows. Only the earliest
+// threadChirpLimit chirps are loaded, so very large conversations are cut
+// short rather than read whole.
+const (
+	defaultThreadDepth = 10
+	maxThreadDepth     = 50
+	threadChirpLimit   = 1000
+)
+
+// ThreadNode is one chirp in a conversation tree. Chirps that have been
+// deleted but still have replies are kept as placeholders with a nil Chirp so
+// that their replies stay visible.
+type ThreadNode struct {
+	ID         uuid.UUID     `json:"id"`
+	Deleted    bool          `json:"deleted"`
+	Chirp      *Chirp        `json:"chirp"`
+	ReplyCount int           `json:"reply_count"`
+	Replies    []*ThreadNode `json:"replies"`
+}
+
+// buildThread arranges the chirps of a conversation into a tree under rootID.
+// Replies whose parent no longer exists hang off a deleted placeholder for
+// that parent. Since a deleted chirp's own parent is unknown, those
+// placeholders are attached to the root.
+func buildThread(rootID uuid.UUID, chirps []Chirp) *ThreadNode {
+	nodes := map[uuid.UUID]*ThreadNode{}
+	for i := range chirps {
+		nodes[chirps[i].ID] = &ThreadNode{
+			ID:      chirps[i].ID,
+			Chirp:   &chirps[i],
+			Replies: []*ThreadNode{},
+		}
+	}
+
+	placeholder := func(id uuid.UUID) *ThreadNode {
+		node := &ThreadNode{
+			ID:      id,
+			Deleted: true,
+			Replies: []*ThreadNode{},
+		}
+		nodes[id] = node
+		return node
+	}
+
+	root, ok := nodes[rootID]
+	if !ok {
+		root = placeholder(rootID)
+	}
+
+	// chirps are ordered by creation time, so replies stay in order
+	for _, chirp := range chirps {
+		if chirp.ID == rootID || chirp.InReplyTo == nil {
+			continue
+		}
+		parent, ok := nodes[*chirp.InReplyTo]
+		if !ok {
+			parent = placeholder(*chirp.InReplyTo)
+			root.Replies = append(root.Replies, parent)
+			root.ReplyCount++
+		}
+		parent.Replies = append(parent.Replies, nodes[chirp.ID])
+		parent.ReplyCount++
+	}
+	return root
+}
+
+// pruneThread drops replies deeper than maxDepth. Reply counts are left as is
+// so clients can tell that more replies exist.
+func pruneThread(node *ThreadNode, maxDepth int) {
+	if maxDepth <= 0 {
+		node.Replies = []*ThreadNode{}
+		return
+	}
+	for _, reply := range node.Replies {
+		pruneThread(reply, maxDepth-1)
+	}
+}
+
+func (cfg *apiConfig) handlerGetChirpThread(w http.ResponseWriter, r *http.Request) {
+
+	chirpID, err := uuid.Parse(r.PathValue("chirpID"))
+	if err != nil {
+		ResponseError(w, err, "Error parsing chirp id", http.StatusBadRequest)
+		return
+	}
+
+	depth := defaultThreadDepth
+	if value := r.URL.Query().Get("depth"); value != "" {
+		depth, err = strconv.Atoi(value)
+		if err != nil || depth < 0 || depth > maxThreadDepth {
+			ResponseError(w, nil, "Invalid thread depth", http.StatusBadRequest)
+			return
+		}
+	}
+
+	viewer := cfg.optionalViewer(r)
+	dbChirp, err := cfg.getVisibleChirp(context.Background(), chirpID, viewer)
+	if err != nil {
+		ResponseError(w, err, "Chirp ID not found", http.StatusNotFound)
+		return
+	}
+
+	// Hidden chirps are left out, so like deleted ones they only show up as
+	// placeholders for their replies. The chirp that was asked for is kept
+	// even if its author is muted. One level more than is shown is loaded so
+	// the deepest chirps shown have their replies counted.
+	dbChirps, err := cfg.db.GetChirpThread(context.Background(), database.GetChirpThreadParams{
+		RootID:   dbChirp.RootID,
+		MaxDepth: int32(depth + 1),
+		ChirpID:  dbChirp.ID,
+		ViewerID: viewer,
+		RowLimit: threadChirpLimit,
+	})
+	if err != nil {
+		ResponseError(w, err, "Error retrieving thread", http.StatusInternalServerError)
+		return
+	}
+
+	chirps, err := cfg.buildChirps(context.Background(), dbChirps, viewer)
+	if err != nil {
+		ResponseError(w, err, "Error retrieving chirp details", http.StatusInternalServerError)
+		return
+	}
+
+	thread := buildThread(dbChirp.RootID, chirps)
+	pruneThread(thread, depth)
+
+	data, err := json.Marshal(thread)
+	SetJSONResponse(w, http.StatusOK, data, err)
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/jthughes/chirpynetwork/internal/database"
+	"github.com/jthughes/chirpynetwork/internal/pagination"
+	"github.com/jthughes/chirpynetwork/internal/timeline"
+)
+
+// getHomeTimeline fetches one page of the chirps posted by the accounts
+// userID follows, newest first. Chirps posted while their author was below
+// the fan-out limit were fanned out into the user's inbox; the rest are read
+// from the chirps table and merged in. Muted accounts are left out.
+func (cfg *apiConfig) getHomeTimeline(ctx context.Context, userID uuid.UUID, page pagination.Request) ([]database.Chirp, *pagination.Cursor, error) {
+
+	// Fetch one extra row from each source to find out whether there is a next page
+	inbox, err := cfg.db.ListInboxChirps(ctx, database.ListInboxChirpsParams{
+		UserID:          userID,
+		HasCursor:       page.HasCursor,
+		CursorCreatedAt: page.Cursor.CreatedAt,
+		CursorID:        page.Cursor.ID,
+		ViewerID:        uuid.NullUUID{UUID: userID, Valid: true},
+		RowLimit:        int32(page.Limit + 1),
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	pulled, err := cfg.db.ListPulledChirps(ctx, database.ListPulledChirpsParams{
+		UserID:          userID,
+		HasCursor:       page.HasCursor,
+		CursorCreatedAt: page.Cursor.CreatedAt,
+		CursorID:        page.Cursor.ID,
+		ViewerID:        uuid.NullUUID{UUID: userID, Valid: true},
+		RowLimit:        int32(page.Limit + 1),
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	dbChirps, next := pagination.Trim(timeline.Merge(inbox, pulled), page.Limit, chirpCursor)
+	return dbChirps, next, nil
+}
+
+func (cfg *apiConfig) handlerGetTimeline(w http.ResponseWriter, r *http.Request) {
+
+	userID, err := cfg.authenticateRequest(r)
+	if err != nil {
+		ResponseError(w, err, "User not authenticated", http.StatusUnauthorized)
+		return
+	}
+
+	page, err := pagination.ParseRequest(r.URL.Query())
+	if err != nil {
+		ResponseError(w, err, "Invalid pagination parameters", http.StatusBadRequest)
+		return
+	}
+
+	dbChirps, next, err := cfg.getHomeTimeline(context.Background(), userID, page)
+	if err != nil {
+		ResponseError(w, err, "Error retrieving timeline", http.StatusInternalServerError)
+		return
+	}
+
+	chirps, err := cfg.buildChirps(context.Background(), dbChirps, uuid.NullUUID{UUID: userID, Valid: true})
+	if err != nil {
+		ResponseError(w, err, "Error retrieving chirp details", http.StatusInternalServerError)
+		return
+	}
+
+	SetNextCursor(w, r, next)
+	data, err := json.Marshal(chirps)
+	SetJSONResponse(w, http.StatusOK, data, err)
+}
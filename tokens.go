@@ -0,0 +1,225 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jthughes/chirpynetwork/internal/auth"
+	"github.com/jthughes/chirpynetwork/internal/database"
+	"github.com/jthughes/chirpynetwork/internal/textlen"
+)
+
+// Limits on personal access tokens, with names counted in user-perceived
+// characters
+const (
+	tokenNameLengthLimit = 50
+	userTokenLimit       = 50
+)
+
+// PersonalAccessToken describes a personal access token. The token itself is
+// only ever sent once, when it is created.
+type PersonalAccessToken struct {
+	ID         uuid.UUID  `json:"id"`
+	CreatedAt  time.Time  `json:"created_at"`
+	Name       string     `json:"name"`
+	Scopes     []string   `json:"scopes"`
+	ExpiresAt  *time.Time `json:"expires_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+}
+
+func dbTokenToPersonalAccessToken(dbToken database.PersonalAccessToken) PersonalAccessToken {
+	token := PersonalAccessToken{
+		ID:        dbToken.ID,
+		CreatedAt: dbToken.CreatedAt,
+		Name:      dbToken.Name,
+		Scopes:    dbToken.Scopes,
+	}
+	if token.Scopes == nil {
+		token.Scopes = []string{}
+	}
+	if dbToken.ExpiresAt.Valid {
+		token.ExpiresAt = &dbToken.ExpiresAt.Time
+	}
+	if dbToken.LastUsedAt.Valid {
+		token.LastUsedAt = &dbToken.LastUsedAt.Time
+	}
+	return token
+}
+
+// validatePersonalAccessToken looks up a personal access token by its digest
+// and returns the claims it grants.
+func (cfg *apiConfig) validatePersonalAccessToken(ctx context.Context, token string) (auth.Claims, error) {
+	dbToken, err := cfg.db.GetPersonalAccessTokenByHash(ctx, auth.HashToken(token))
+	if err != nil {
+		return auth.Claims{}, fmt.Errorf("personal access token not found")
+	}
+	now := time.Now()
+	if dbToken.ExpiresAt.Valid && dbToken.ExpiresAt.Time.Before(now) {
+		return auth.Claims{}, fmt.Errorf("personal access token expired")
+	}
+
+	err = cfg.db.TouchPersonalAccessToken(ctx, database.TouchPersonalAccessTokenParams{
+		ID:  dbToken.ID,
+		Now: now,
+	})
+	if err != nil {
+		log.Printf("Error recording use of personal access token %s: %s", dbToken.ID, err)
+	}
+
+	return auth.Claims{
+		UserID:              dbToken.UserID,
+		Scopes:              dbToken.Scopes,
+		ExpiresAt:           dbToken.ExpiresAt.Time,
+		PersonalAccessToken: true,
+	}, nil
+}
+
+func (cfg *apiConfig) handlerCreateToken(w http.ResponseWriter, r *http.Request) {
+	type request struct {
+		Name      string     `json:"name"`
+		Scopes    []string   `json:"scopes"`
+		ExpiresAt *time.Time `json:"expires_at"`
+	}
+
+	claims, ok := principal(r)
+	if !ok {
+		ResponseError(w, nil, "User not authenticated", http.StatusUnauthorized)
+		return
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	test := request{}
+	err := decoder.Decode(&test)
+	if err != nil {
+		ResponseError(w, err, "Error decoding request", http.StatusBadRequest)
+		return
+	}
+
+	test.Name = strings.TrimSpace(test.Name)
+	if test.Name == "" || textlen.Graphemes(test.Name) > tokenNameLengthLimit {
+		ResponseError(w, nil, fmt.Sprintf("Name must be 1 to %d characters", tokenNameLengthLimit), http.StatusBadRequest)
+		return
+	}
+
+	// A token can't grant more than the token creating it, and personal
+	// access tokens can't create tokens at all
+	if test.Scopes == nil {
+		test.Scopes = []string{}
+	}
+	err = claims.CheckNewToken(test.Scopes, test.ExpiresAt, time.Now())
+	if errors.Is(err, auth.ErrTokenFromToken) {
+		ResponseError(w, err, "Personal access tokens can only be created from a login", http.StatusForbidden)
+		return
+	}
+	if err != nil {
+		ResponseError(w, err, fmt.Sprintf("Invalid token: %s", err), http.StatusBadRequest)
+		return
+	}
+
+	count, err := cfg.db.CountUserPersonalAccessTokens(context.Background(), claims.UserID)
+	if err != nil {
+		ResponseError(w, err, "Error creating token", http.StatusInternalServerError)
+		return
+	}
+	if count >= userTokenLimit {
+		ResponseError(w, nil, fmt.Sprintf("Users can have at most %d personal access tokens", userTokenLimit), http.StatusBadRequest)
+		return
+	}
+
+	token, err := auth.MakePersonalAccessToken()
+	if err != nil {
+		ResponseError(w, err, "Error creating token", http.StatusInternalServerError)
+		return
+	}
+
+	expiresAt := sql.NullTime{}
+	if test.ExpiresAt != nil {
+		expiresAt = sql.NullTime{Time: *test.ExpiresAt, Valid: true}
+	}
+	now := time.Now()
+	dbToken, err := cfg.db.CreatePersonalAccessToken(context.Background(), database.CreatePersonalAccessTokenParams{
+		ID:        uuid.New(),
+		CreatedAt: now,
+		UpdatedAt: now,
+		UserID:    claims.UserID,
+		Name:      test.Name,
+		TokenHash: auth.HashToken(token),
+		Scopes:    test.Scopes,
+		ExpiresAt: expiresAt,
+	})
+	if err != nil {
+		ResponseError(w, err, "Error storing token", http.StatusInternalServerError)
+		return
+	}
+
+	type response struct {
+		PersonalAccessToken
+		Token string `json:"token"`
+	}
+	data, err := json.Marshal(response{
+		PersonalAccessToken: dbTokenToPersonalAccessToken(dbToken),
+		Token:               token,
+	})
+	SetJSONResponse(w, http.StatusCreated, data, err)
+}
+
+func (cfg *apiConfig) handlerGetTokens(w http.ResponseWriter, r *http.Request) {
+
+	userID, err := cfg.authenticateRequest(r)
+	if err != nil {
+		ResponseError(w, err, "User not authenticated", http.StatusUnauthorized)
+		return
+	}
+
+	dbTokens, err := cfg.db.ListUserPersonalAccessTokens(context.Background(), userID)
+	if err != nil {
+		ResponseError(w, err, "Error retrieving tokens", http.StatusInternalServerError)
+		return
+	}
+
+	out := []PersonalAccessToken{}
+	for _, dbToken := range dbTokens {
+		out = append(out, dbTokenToPersonalAccessToken(dbToken))
+	}
+
+	data, err := json.Marshal(out)
+	SetJSONResponse(w, http.StatusOK, data, err)
+}
+
+func (cfg *apiConfig) handlerRevokeToken(w http.ResponseWriter, r *http.Request) {
+
+	userID, err := cfg.authenticateRequest(r)
+	if err != nil {
+		ResponseError(w, err, "User not authenticated", http.StatusUnauthorized)
+		return
+	}
+
+	tokenID, err := uuid.Parse(r.PathValue("tokenID"))
+	if err != nil {
+		ResponseError(w, err, "Error parsing token id", http.StatusBadRequest)
+		return
+	}
+
+	deleted, err := cfg.db.DeletePersonalAccessToken(context.Background(), database.DeletePersonalAccessTokenParams{
+		ID:     tokenID,
+		UserID: userID,
+	})
+	if err != nil {
+		ResponseError(w, err, "Error revoking token", http.StatusInternalServerError)
+		return
+	}
+	if deleted == 0 {
+		ResponseError(w, nil, "Token not found", http.StatusNotFound)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
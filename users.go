@@ -13,20 +13,36 @@ import (
 
 // handlerLogin expects this to not have a copy of Password
 type User struct {
-	ID          uuid.UUID `json:"id"`
-	CreatedAt   time.Time `json:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at"`
-	Email       string    `json:"email"`
-	IsChirpyRed bool      `json:"is_chirpy_red"`
+	ID             uuid.UUID `json:"id"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+	Email          string    `json:"email"`
+	Handle         *string   `json:"handle"`
+	DisplayName    string    `json:"display_name"`
+	Bio            string    `json:"bio"`
+	Location       string    `json:"location"`
+	Website        string    `json:"website"`
+	IsChirpyRed    bool      `json:"is_chirpy_red"`
+	FollowerCount  int32     `json:"follower_count"`
+	FollowingCount int32     `json:"following_count"`
+	Protected      bool      `json:"protected"`
 }
 
 func dbUserToUser(dbUser database.User) User {
 	return User{
-		ID:          dbUser.ID,
-		CreatedAt:   dbUser.CreatedAt,
-		UpdatedAt:   dbUser.UpdatedAt,
-		Email:       dbUser.Email,
-		IsChirpyRed: dbUser.IsChirpyRed,
+		ID:             dbUser.ID,
+		CreatedAt:      dbUser.CreatedAt,
+		UpdatedAt:      dbUser.UpdatedAt,
+		Email:          dbUser.Email,
+		Handle:         nullStringPtr(dbUser.Handle),
+		DisplayName:    dbUser.DisplayName,
+		Bio:            dbUser.Bio,
+		Location:       dbUser.Location,
+		Website:        dbUser.Website,
+		IsChirpyRed:    dbUser.IsChirpyRed,
+		FollowerCount:  dbUser.FollowerCount,
+		FollowingCount: dbUser.FollowingCount,
+		Protected:      dbUser.Protected,
 	}
 }
 
@@ -64,13 +80,23 @@ func (cfg *apiConfig) handlerCreateUser(w http.ResponseWriter, r *http.Request)
 
 func (cfg *apiConfig) handlerUpdateLogin(w http.ResponseWriter, r *http.Request) {
 	type request struct {
-		Email    string `json:"email"`
-		Password string `json:"password"`
+		Email               string `json:"email"`
+		Password            string `json:"password"`
+		RevokeOtherSessions bool   `json:"revoke_other_sessions"`
 	}
 
-	userID, err := cfg.authenticateRequest(r)
+	claims, ok := principal(r)
+	if !ok {
+		ResponseError(w, nil, "User not authenticated", http.StatusUnauthorized)
+		return
+	}
+	userID, sessionID := claims.UserID, claims.SessionID
+
+	// Personal access tokens can't change the login, or a leaked one could
+	// be turned into the whole account
+	err := claims.CheckChangeLogin()
 	if err != nil {
-		ResponseError(w, err, "User not authenticated", http.StatusUnauthorized)
+		ResponseError(w, err, "The login can only be changed from a login", http.StatusForbidden)
 		return
 	}
 
@@ -98,6 +124,49 @@ func (cfg *apiConfig) handlerUpdateLogin(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
+	// Log out everywhere else, so whoever may have learned the old password
+	// loses access along with it
+	if test.RevokeOtherSessions {
+		err = cfg.revokeSessions(context.Background(), userID, sessionID)
+		if err != nil {
+			ResponseError(w, err, "Error revoking sessions", http.StatusInternalServerError)
+			return
+		}
+	}
+
+	user := dbUserToUser(dbUser)
+	data, err := json.Marshal(user)
+	SetJSONResponse(w, http.StatusOK, data, err)
+}
+
+func (cfg *apiConfig) handlerSetProtected(w http.ResponseWriter, r *http.Request) {
+	type request struct {
+		Protected bool `json:"protected"`
+	}
+
+	userID, err := cfg.authenticateRequest(r)
+	if err != nil {
+		ResponseError(w, err, "User not authenticated", http.StatusUnauthorized)
+		return
+	}
+
+	decoder := json.NewDecoder(r.Body)
+	test := request{}
+	err = decoder.Decode(&test)
+	if err != nil {
+		ResponseError(w, err, "Error decoding request", http.StatusBadRequest)
+		return
+	}
+
+	dbUser, err := cfg.db.SetUserProtected(context.Background(), database.SetUserProtectedParams{
+		ID:        userID,
+		Protected: test.Protected,
+	})
+	if err != nil {
+		ResponseError(w, err, "Error updating user", http.StatusInternalServerError)
+		return
+	}
+
 	user := dbUserToUser(dbUser)
 	data, err := json.Marshal(user)
 	SetJSONResponse(w, http.StatusOK, data, err)